@@ -0,0 +1,169 @@
+// eywa-local runs a robot outside of EYWA
+//
+// The robot is started as a child process and eywa-local plays the EYWA
+// runtime on its stdio: task.get is served from a JSON file, GraphQL is
+// answered from a stub file or forwarded to an endpoint, and logs, status
+// changes and reports are printed in readable form.
+//
+// Usage:
+//
+//	eywa-local [flags] command [args...]
+//	eywa-local -task examples/test-task.json go run examples/task_reporting_demo.go
+//	eywa-local -graphql-stub fixtures.json ./my-robot
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/neyho/eywa-go"
+)
+
+func main() {
+	taskFile := flag.String("task", eywa.DefaultTaskFile, "JSON file served as the task")
+	endpoint := flag.String("graphql", os.Getenv(eywa.EnvGraphQLEndpoint), "GraphQL endpoint to forward queries to")
+	token := flag.String("token", os.Getenv(eywa.EnvGraphQLToken), "Bearer token for the GraphQL endpoint")
+	stubFile := flag.String("graphql-stub", os.Getenv(eywa.EnvGraphQLStub), "JSON file with GraphQL responses")
+	noColor := flag.Bool("no-color", os.Getenv("NO_COLOR") != "", "Disable colored output")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: eywa-local [flags] command [args...]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	runtime := &localRuntime{
+		console: eywa.NewConsole(os.Stdout, !*noColor && eywa.IsTerminal(os.Stdout)),
+		local: &eywa.LocalRuntime{
+			TaskFile: *taskFile,
+			GraphQL: &eywa.LocalGraphQL{
+				Endpoint: *endpoint,
+				Token:    *token,
+				StubFile: *stubFile,
+			},
+		},
+	}
+
+	code, err := runtime.run(flag.Arg(0), flag.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "eywa-local: %v\n", err)
+	}
+	os.Exit(code)
+}
+
+// localRuntime speaks the EYWA JSON-RPC protocol with one robot process
+type localRuntime struct {
+	console *eywa.Console
+	local   *eywa.LocalRuntime
+
+	mu    sync.Mutex
+	stdin io.Writer
+}
+
+// run starts the robot and serves it until it exits, returning its exit code
+func (r *localRuntime) run(name string, args []string) (int, error) {
+	cmd := exec.Command(name, args...)
+	// The robot must speak JSON-RPC, not render its own dev console
+	cmd.Env = append(os.Environ(), eywa.EnvDev+"=0")
+	cmd.Stderr = os.Stderr
+	isolateProcessGroup(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return 1, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 1, err
+	}
+	r.stdin = stdin
+
+	if err := cmd.Start(); err != nil {
+		return 1, err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for sig := range signals {
+			signalRobot(cmd, sig)
+		}
+	}()
+
+	r.serve(stdout)
+
+	err = cmd.Wait()
+	signal.Stop(signals)
+	close(signals)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+// serve reads messages from the robot until its stdout closes. Lines that
+// are not JSON-RPC messages are printed as they are.
+func (r *localRuntime) serve(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var pending sync.WaitGroup
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		var message map[string]interface{}
+		if err := json.Unmarshal(line, &message); err != nil || message["method"] == nil {
+			fmt.Fprintln(os.Stdout, string(line))
+			continue
+		}
+
+		method, _ := message["method"].(string)
+		if id, isRequest := message["id"]; isRequest && id != nil {
+			pending.Add(1)
+			go func() {
+				defer pending.Done()
+				r.respond(r.local.Handle(method, message["id"], message["params"]))
+			}()
+			continue
+		}
+		r.console.Message(method, message["params"])
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "eywa-local: reading robot output: %v\n", err)
+	}
+	pending.Wait()
+}
+
+// respond writes a response line to the robot's stdin
+func (r *localRuntime) respond(response eywa.Response) {
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "eywa-local: cannot encode response: %v\n", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.stdin.Write(append(encoded, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "eywa-local: cannot write to robot: %v\n", err)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// isolateProcessGroup starts the robot in its own process group, so a
+// Ctrl+C in the terminal reaches it once, forwarded by eywa-local, and not
+// a second time directly from the terminal
+func isolateProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalRobot forwards sig to the whole process group of the robot. With
+// `go run` the direct child is the go tool, which ignores SIGINT and SIGTERM
+// and does not pass them on to the robot binary it started.
+func signalRobot(cmd *exec.Cmd, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return cmd.Process.Signal(sig)
+	}
+	return syscall.Kill(-cmd.Process.Pid, s)
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// isolateProcessGroup is not needed on Windows, console signals are not
+// forwarded there
+func isolateProcessGroup(cmd *exec.Cmd) {}
+
+// signalRobot forwards sig to the robot process
+func signalRobot(cmd *exec.Cmd, sig os.Signal) error {
+	return cmd.Process.Signal(sig)
+}
@@ -9,20 +9,14 @@ import (
 
 func processTask() error {
 	// Get current task
-	task, err := eywa.GetTask()
+	task, err := eywa.GetTaskInfo()
 	if err != nil {
 		return fmt.Errorf("failed to get task: %w", err)
 	}
 
-	// Extract task info
-	taskData, ok := task.(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("unexpected task format")
-	}
-
 	eywa.Info("Processing task", map[string]interface{}{
-		"task_id": taskData["euuid"],
-		"message": taskData["message"],
+		"task_id": task.EUUID,
+		"message": task.Message,
 	})
 
 	// Update status to processing
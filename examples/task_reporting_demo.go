@@ -17,16 +17,11 @@ func main() {
 	time.Sleep(100 * time.Millisecond)
 	
 	// Get current task info for debugging
-	taskData, err := eywa.GetTask()
+	task, err := eywa.GetTaskInfo()
 	if err != nil {
 		log.Fatalf("Failed to get task: %v", err)
 	}
-	
-	if taskMap, ok := taskData.(map[string]interface{}); ok {
-		if euuid, exists := taskMap["euuid"]; exists {
-			fmt.Printf("Running task: %v\n", euuid)
-		}
-	}
+	fmt.Printf("Running task: %s\n", task.EUUID)
 
 	// Test 1: Simple card report
 	fmt.Println("📝 Test 1: Simple card report")
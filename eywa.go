@@ -2,10 +2,12 @@ package eywa
 
 import (
 	"bufio"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
 	"os"
 	"strings"
@@ -15,10 +17,10 @@ import (
 
 // Task status constants
 const (
-	SUCCESS    = "SUCCESS"
-	ERROR      = "ERROR"
-	PROCESSING = "PROCESSING"
-	EXCEPTION  = "EXCEPTION"
+	SUCCESS    Status = "SUCCESS"
+	ERROR      Status = "ERROR"
+	PROCESSING Status = "PROCESSING"
+	EXCEPTION  Status = "EXCEPTION"
 )
 
 // Log event types
@@ -88,7 +90,21 @@ type ReportParams struct {
 
 // TaskParams represents task-related parameters
 type TaskParams struct {
-	Status string `json:"status"`
+	Status    Status      `json:"status,omitempty"`
+	Message   string      `json:"message,omitempty"`
+	Progress  *float64    `json:"progress,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	Result    interface{} `json:"result,omitempty"`
+	Files     []string    `json:"files,omitempty"`
+	ResumeAt  *time.Time  `json:"resume_at,omitempty"`
+	Condition string      `json:"condition,omitempty"`
+}
+
+// TaskUpdateOptions carries the optional fields of a task update
+type TaskUpdateOptions struct {
+	Message  string      `json:"message,omitempty"`  // Human-readable task message
+	Progress *float64    `json:"progress,omitempty"` // Completion percentage between 0 and 100
+	Data     interface{} `json:"data,omitempty"`     // Custom structured data
 }
 
 // GraphQLParams represents GraphQL query parameters
@@ -117,12 +133,27 @@ func RegisterHandler(method string, handler func(Request)) {
 
 // SendRequest sends a JSON-RPC request and returns a channel for the response
 func SendRequest(data map[string]interface{}) chan Response {
+	return sendRequestContext(context.Background(), data)
+}
+
+// sendRequestContext sends a JSON-RPC request carrying the trace of ctx
+func sendRequestContext(ctx context.Context, data map[string]interface{}) chan Response {
 	id := generateID()
 	data["jsonrpc"] = "2.0"
 	data["id"] = id
+	applyTrace(ctx, data)
+	applyTaskScope(ctx, data)
 
 	// Create a channel for the response and store it
 	responseChan := make(chan Response, 1)
+
+	// Without an EYWA runtime the request is answered locally
+	if dev := activeDevRuntime(); dev != nil {
+		responseChan <- dev.request(data)
+		close(responseChan)
+		return responseChan
+	}
+
 	mu.Lock()
 	rpcCallbacks[id] = responseChan
 	mu.Unlock()
@@ -134,12 +165,28 @@ func SendRequest(data map[string]interface{}) chan Response {
 
 // SendNotification sends a JSON-RPC notification (no response expected)
 func SendNotification(data map[string]interface{}) {
+	sendNotificationContext(context.Background(), data)
+}
+
+// sendNotificationContext sends a JSON-RPC notification carrying the trace of ctx
+func sendNotificationContext(ctx context.Context, data map[string]interface{}) {
 	data["jsonrpc"] = "2.0"
+	applyTrace(ctx, data)
+	applyTaskScope(ctx, data)
+	if dev := activeDevRuntime(); dev != nil {
+		dev.notify(data)
+		return
+	}
 	sendJSON(data)
 }
 
-// OpenPipe starts listening for incoming JSON-RPC messages on stdin
+// OpenPipe starts listening for incoming JSON-RPC messages on stdin.
+// In dev mode there is no runtime to listen to and OpenPipe returns immediately.
 func OpenPipe() {
+	if IsDevMode() {
+		return
+	}
+
 	scanner := bufio.NewScanner(os.Stdin)
 	// Increase buffer size for large JSON responses
 	buf := make([]byte, 0, 64*1024)
@@ -161,6 +208,11 @@ func OpenPipe() {
 
 // Log sends a log message with full control over parameters
 func Log(event, message string, data interface{}, duration *int, coordinates interface{}, logTime *time.Time) {
+	logContext(context.Background(), event, message, data, duration, coordinates, logTime)
+}
+
+// logContext builds a log entry within ctx
+func logContext(ctx context.Context, event, message string, data interface{}, duration *int, coordinates interface{}, logTime *time.Time) {
 	params := LogParams{
 		Event:       event,
 		Message:     message,
@@ -176,7 +228,30 @@ func Log(event, message string, data interface{}, duration *int, coordinates int
 		params.Time = &now
 	}
 	
-	SendNotification(map[string]interface{}{
+	emitLog(ctx, params)
+}
+
+// emitLog makes a log entry safe to encode, applies redaction, trace fields
+// and size limits, captures it and sends it to EYWA
+func emitLog(ctx context.Context, params LogParams) {
+	if lifecycleFor(ctx).isClosed() {
+		lifecycleWarning("dropping log after task close: %s", params.Message)
+		return
+	}
+
+	coordinates, err := validateLogCoordinates(params.Coordinates)
+	if err != nil {
+		log.Printf("Dropping invalid log coordinates: %v", err)
+	}
+	params.Coordinates = coordinates
+
+	params.Message = redactedString(params.Message)
+	params.Data = withTraceData(ctx, redactedValue(params.Data))
+	params.Coordinates = sanitizeValue(params.Coordinates)
+	enforceEntrySize(&params)
+	captureLog(params)
+
+	sendNotificationContext(ctx, map[string]interface{}{
 		"method": "task.log",
 		"params": params,
 	})
@@ -215,29 +290,26 @@ func Exception(message string, data interface{}) {
 // Report creates a structured task report following EYWA schema exactly
 // Matches the corrected Node.js implementation
 func Report(message string, options *ReportOptions) error {
+	return reportContext(context.Background(), message, options)
+}
+
+// reportContext creates a task report within ctx
+func reportContext(ctx context.Context, message string, options *ReportOptions) error {
+	if lifecycleFor(ctx).isClosed() {
+		lifecycleWarning("dropping report after task close: %s", message)
+		return ErrTaskClosed
+	}
+
 	// Get current task UUID
-	taskData, err := GetTask()
+	task, err := currentTaskFor(ctx)
 	if err != nil {
 		return fmt.Errorf("cannot create report: no active task found: %v", err)
 	}
-	
-	// Extract UUID from task data
-	var currentTaskUUID string
-	if taskMap, ok := taskData.(map[string]interface{}); ok {
-		if euuid, exists := taskMap["euuid"]; exists {
-			currentTaskUUID = fmt.Sprintf("%v", euuid)
-		} else if id, exists := taskMap["id"]; exists {
-			currentTaskUUID = fmt.Sprintf("%v", id)
-		} else {
-			return fmt.Errorf("task UUID not found in task data")
-		}
-	} else {
-		return fmt.Errorf("invalid task data format")
-	}
+	currentTaskUUID := task.EUUID
 	
 	// Build report data structure
 	reportData := ReportParams{
-		Message: message,
+		Message: redactString(message),
 		Task: map[string]interface{}{
 			"euuid": currentTaskUUID,
 		},
@@ -245,7 +317,7 @@ func Report(message string, options *ReportOptions) error {
 	
 	// Process data and set flags
 	if options != nil && options.Data != nil {
-		reportData.Data = options.Data
+		reportData.Data = redactReportData(options.Data)
 		reportData.HasCard = len(options.Data.Card) > 0
 		reportData.HasTable = len(options.Data.Tables) > 0
 	} else {
@@ -275,7 +347,7 @@ func Report(message string, options *ReportOptions) error {
 	// The Task Report entity only supports: message, data, image, has_* flags
 	
 	// Send report via JSON-RPC
-	SendNotification(map[string]interface{}{
+	sendNotificationContext(ctx, map[string]interface{}{
 		"method": "task.report",
 		"params": reportData,
 	})
@@ -297,14 +369,83 @@ func ReportWithCard(message, card string) error {
 	})
 }
 
-// UpdateTask updates the current task status
-func UpdateTask(status string) {
-	SendNotification(map[string]interface{}{
+// UpdateTask updates the current task status. Unknown statuses, updates
+// after close and PROCESSING after a failure are rejected with an error and
+// a warning on stderr.
+func UpdateTask(status Status) error {
+	return UpdateTaskWith(status, nil)
+}
+
+// UpdateTaskWith updates the task status together with its message,
+// progress percentage and custom data. An empty status keeps the current one.
+//
+// Example:
+//
+//	progress := 62.0
+//	eywa.UpdateTaskWith(eywa.PROCESSING, &eywa.TaskUpdateOptions{
+//	    Message:  "Importing invoices",
+//	    Progress: &progress,
+//	    Data:     map[string]interface{}{"imported": 620},
+//	})
+func UpdateTaskWith(status Status, options *TaskUpdateOptions) error {
+	return updateTaskContext(context.Background(), status, options)
+}
+
+// updateTaskContext updates the task of ctx
+func updateTaskContext(ctx context.Context, status Status, options *TaskUpdateOptions) error {
+	params := TaskParams{
+		Status: status,
+	}
+	if options != nil {
+		if options.Progress != nil {
+			progress := *options.Progress
+			if math.IsNaN(progress) || progress < 0 || progress > 100 {
+				return fmt.Errorf("task progress must be between 0 and 100: %v", progress)
+			}
+			params.Progress = &progress
+		}
+		params.Message = redactedString(options.Message)
+		params.Data = redactedValue(options.Data)
+	}
+
+	if err := lifecycleFor(ctx).update(status); err != nil {
+		lifecycleWarning("UpdateTask(%s): %v", status, err)
+		return err
+	}
+
+	sendNotificationContext(ctx, map[string]interface{}{
 		"method": "task.update",
-		"params": TaskParams{
-			Status: status,
-		},
+		"params": params,
 	})
+	return nil
+}
+
+// closeTaskContext closes the task of ctx without exiting. beforeSend runs
+// once the close is accepted, before task.close is sent.
+func closeTaskContext(ctx context.Context, params TaskParams, beforeSend func()) (Status, error) {
+	l := lifecycleFor(ctx)
+	status, err := l.beginClose(params.Status)
+	if err == ErrTaskClosed {
+		lifecycleWarning("CloseTask(%s): %v", status, err)
+		return status, err
+	}
+	if err != nil {
+		lifecycleWarning("CloseTask: %v", err)
+	}
+
+	params.Status = status
+	if status == SUCCESS {
+		clearLoadedContinuation(ctx)
+	}
+	if beforeSend != nil {
+		beforeSend()
+	}
+	sendNotificationContext(ctx, map[string]interface{}{
+		"method": "task.close",
+		"params": params,
+	})
+	l.finishClose(status)
+	return status, nil
 }
 
 // GetTask retrieves the current task information
@@ -322,33 +463,49 @@ func GetTask() (interface{}, error) {
 	return response.Result, nil
 }
 
-// ReturnTask returns control to EYWA without closing the task
+// ReturnTask returns control to EYWA without closing the task. Use
+// ReturnTaskWith to hand over state and schedule the resume.
 func ReturnTask() {
+	if lifecycle.isClosed() {
+		lifecycleWarning("ReturnTask: %v", ErrTaskClosed)
+		return
+	}
+	uploadLogCapture()
 	SendNotification(map[string]interface{}{
 		"method": "task.return",
 	})
 	os.Exit(0)
 }
 
-// CloseTask closes the current task with a status
-func CloseTask(status string) {
-	SendNotification(map[string]interface{}{
-		"method": "task.close",
-		"params": TaskParams{
-			Status: status,
-		},
-	})
-	
+// CloseTask closes the current task with SUCCESS, ERROR or EXCEPTION and
+// exits. Any other status is replaced by ERROR with a warning. Closing a
+// task twice returns ErrTaskClosed without exiting.
+func CloseTask(status Status) error {
+	return closeTask(TaskParams{Status: status})
+}
+
+// closeTask sends task.close with params and exits
+func closeTask(params TaskParams) error {
+	status, err := closeTaskContext(context.Background(), params, uploadLogCapture)
+	if err != nil {
+		return err
+	}
+
 	if status == SUCCESS {
 		os.Exit(0)
-	} else {
-		os.Exit(1)
 	}
+	os.Exit(1)
+	return nil
 }
 
 // GraphQL executes a GraphQL query
 func GraphQL(query string, variables map[string]interface{}) (map[string]interface{}, error) {
-	responseChan := SendRequest(map[string]interface{}{
+	return graphQLContext(context.Background(), query, variables)
+}
+
+// graphQLContext executes a GraphQL query carrying the trace of ctx
+func graphQLContext(ctx context.Context, query string, variables map[string]interface{}) (map[string]interface{}, error) {
+	responseChan := sendRequestContext(ctx, map[string]interface{}{
 		"method": "eywa.datasets.graphql",
 		"params": GraphQLParams{
 			Query:     query,
@@ -432,15 +589,33 @@ func handleResponse(data map[string]interface{}) {
 	}
 }
 
+// outputMu keeps concurrent messages from interleaving on stdout
+var outputMu sync.Mutex
+
 func sendJSON(data interface{}) {
 	encoded, err := json.Marshal(data)
 	if err != nil {
 		log.Printf("Failed to encode JSON: %v", err)
 		return
 	}
+	outputMu.Lock()
+	defer outputMu.Unlock()
 	fmt.Println(string(encoded))
 }
 
+// sendResponse answers a JSON-RPC request from the runtime
+func sendResponse(id string, result interface{}, err error) {
+	response := Response{
+		JsonRPC: "2.0",
+		Result:  result,
+		ID:      id,
+	}
+	if err != nil {
+		response.Error = map[string]interface{}{"message": err.Error()}
+	}
+	sendJSON(response)
+}
+
 // Validation helper functions (following Node.js implementation)
 
 // isValidBase64 validates base64 string (matches Node.js implementation)
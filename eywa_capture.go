@@ -0,0 +1,223 @@
+// EYWA Session Log Capture for Go
+//
+// Tees every task.log entry into a local file and uploads that file to EYWA
+// when the task is closed or returned, so the complete session log is
+// available as a task artifact for audits.
+
+package eywa
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log capture formats
+const (
+	CaptureJSONL = "jsonl"
+	CaptureText  = "text"
+)
+
+// LogCaptureOptions configures session log capture
+type LogCaptureOptions struct {
+	Path   string                 `json:"path,omitempty"`   // Local file, a temporary file when empty
+	Format string                 `json:"format,omitempty"` // CaptureJSONL (default) or CaptureText
+	Folder map[string]interface{} `json:"folder,omitempty"` // Upload folder: {euuid: string} OR {path: string}
+	Name   string                 `json:"name,omitempty"`   // Uploaded file name, generated when empty
+}
+
+// logCapture holds the state of an active capture
+type logCapture struct {
+	options   LogCaptureOptions
+	file      *os.File
+	writer    *bufio.Writer
+	temporary bool
+	entries   int
+}
+
+var (
+	captureMu sync.Mutex
+	capture   *logCapture
+)
+
+// EnableLogCapture starts writing every log entry to a local file. The file
+// is uploaded via UploadContent when the task is closed or returned and its
+// euuid is linked in a final log entry.
+//
+// Example:
+//
+//	err := eywa.EnableLogCapture(&eywa.LogCaptureOptions{
+//	    Folder: map[string]interface{}{"path": "/audit/robot-logs"},
+//	})
+func EnableLogCapture(options *LogCaptureOptions) error {
+	opts := LogCaptureOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Format == "" {
+		opts.Format = CaptureJSONL
+	}
+	if opts.Format != CaptureJSONL && opts.Format != CaptureText {
+		return fmt.Errorf("unsupported log capture format: %s", opts.Format)
+	}
+
+	var (
+		file      *os.File
+		err       error
+		temporary bool
+	)
+	if opts.Path != "" {
+		file, err = os.OpenFile(opts.Path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	} else {
+		file, err = os.CreateTemp("", "eywa-session-*"+captureExtension(opts.Format))
+		temporary = true
+	}
+	if err != nil {
+		return fmt.Errorf("cannot create log capture file: %v", err)
+	}
+
+	captureMu.Lock()
+	previous := capture
+	capture = &logCapture{
+		options:   opts,
+		file:      file,
+		writer:    bufio.NewWriter(file),
+		temporary: temporary,
+	}
+	captureMu.Unlock()
+
+	if previous != nil {
+		previous.close()
+	}
+	return nil
+}
+
+// captureLog appends an entry to the active capture, if any
+func captureLog(params LogParams) {
+	captureMu.Lock()
+	defer captureMu.Unlock()
+	if capture == nil {
+		return
+	}
+
+	var line string
+	if capture.options.Format == CaptureText {
+		line = formatCapturedLine(params)
+	} else {
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			return
+		}
+		line = string(encoded)
+	}
+
+	capture.writer.WriteString(line)
+	capture.writer.WriteByte('\n')
+	capture.entries++
+}
+
+func captureExtension(format string) string {
+	if format == CaptureText {
+		return ".log"
+	}
+	return ".jsonl"
+}
+
+// formatCapturedLine renders an entry for the text capture format
+func formatCapturedLine(params LogParams) string {
+	timestamp := time.Now()
+	if params.Time != nil {
+		timestamp = *params.Time
+	}
+
+	line := fmt.Sprintf("%s %-9s %s", timestamp.UTC().Format(time.RFC3339Nano), params.Event, params.Message)
+	if params.Duration != nil {
+		line += fmt.Sprintf(" (%dms)", *params.Duration)
+	}
+	if params.Data != nil {
+		line += " " + compactJSON(params.Data)
+	}
+	return strings.ReplaceAll(line, "\n", "\\n")
+}
+
+// finishLogCapture stops the active capture, uploads the captured file and
+// returns its euuid. It returns an empty string when capture is not active.
+func finishLogCapture() (string, error) {
+	captureMu.Lock()
+	active := capture
+	capture = nil
+	captureMu.Unlock()
+
+	if active == nil {
+		return "", nil
+	}
+	if err := active.close(); err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(active.file.Name())
+	if err != nil {
+		return "", fmt.Errorf("cannot read log capture file: %v", err)
+	}
+
+	contentType := "application/x-ndjson"
+	if active.options.Format == CaptureText {
+		contentType = "text/plain"
+	}
+
+	name := active.options.Name
+	if name == "" {
+		name = fmt.Sprintf("task-log-%s%s", time.Now().UTC().Format("20060102-150405"), captureExtension(active.options.Format))
+	}
+
+	euuid := generateUUID()
+	fileData := map[string]interface{}{
+		"euuid":        euuid,
+		"name":         name,
+		"content_type": contentType,
+	}
+	if active.options.Folder != nil {
+		fileData["folder"] = active.options.Folder
+	}
+
+	if err := UploadContent(content, fileData); err != nil {
+		return "", err
+	}
+	active.cleanup()
+
+	Info("Session log uploaded", map[string]interface{}{
+		"file": map[string]interface{}{
+			"euuid": euuid,
+			"name":  name,
+		},
+		"entries": active.entries,
+	})
+	return euuid, nil
+}
+
+// uploadLogCapture finishes the capture before the task ends, reporting
+// failures without preventing the task from closing
+func uploadLogCapture() {
+	if _, err := finishLogCapture(); err != nil {
+		LogErr(WARN, "Session log upload failed", err)
+	}
+}
+
+func (c *logCapture) close() error {
+	if err := c.writer.Flush(); err != nil {
+		c.file.Close()
+		return fmt.Errorf("cannot write log capture file: %v", err)
+	}
+	return c.file.Close()
+}
+
+// cleanup removes a temporary capture file once it was uploaded
+func (c *logCapture) cleanup() {
+	if c.temporary {
+		os.Remove(c.file.Name())
+	}
+}
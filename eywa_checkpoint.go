@@ -0,0 +1,210 @@
+// EYWA Checkpoints for Go
+//
+// Checkpoints persist robot state tied to the task, so a task that EYWA
+// retries resumes where the failed attempt stopped instead of starting
+// from zero. Each checkpoint is a JSON file uploaded with UploadContent
+// under a euuid derived from the task euuid and the checkpoint key, which
+// lets a restarted robot find it again without any bookkeeping. Within a
+// Worker, TaskSession has the same methods for the task of the session.
+
+package eywa
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	checkpointMu     sync.Mutex
+	checkpointFolder map[string]interface{}
+	checkpointCache  = make(map[string][]byte) // Keyed by task euuid and checkpoint key
+)
+
+// SetCheckpointFolder stores checkpoint files in the folder at path. An
+// empty path stores them without a folder.
+func SetCheckpointFolder(path string) {
+	checkpointMu.Lock()
+	defer checkpointMu.Unlock()
+	if path == "" {
+		checkpointFolder = nil
+		return
+	}
+	checkpointFolder = map[string]interface{}{"path": path}
+}
+
+// SaveCheckpoint stores state under key for the current task. Saving the
+// same key again replaces the previous state.
+//
+// Example:
+//
+//	for i := state.Next; i < len(rows); i++ {
+//	    importRow(rows[i])
+//	    if i%1000 == 0 {
+//	        eywa.SaveCheckpoint("import", ImportState{Next: i + 1})
+//	    }
+//	}
+func SaveCheckpoint(key string, state interface{}) error {
+	return saveCheckpoint(context.Background(), key, state)
+}
+
+// saveCheckpoint stores state under key for the task of ctx
+func saveCheckpoint(ctx context.Context, key string, state interface{}) error {
+	euuid, cacheKey, err := checkpointUUID(ctx, key)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("cannot encode checkpoint %s: %v", key, err)
+	}
+
+	checkpointMu.Lock()
+	fileData := map[string]interface{}{
+		"euuid":        euuid,
+		"name":         checkpointFileName(key),
+		"content_type": "application/json",
+	}
+	if checkpointFolder != nil {
+		fileData["folder"] = checkpointFolder
+	}
+	checkpointMu.Unlock()
+
+	if err := uploadContent(ctx, content, fileData, true); err != nil {
+		return fmt.Errorf("cannot save checkpoint %s: %v", key, err)
+	}
+
+	checkpointMu.Lock()
+	checkpointCache[cacheKey] = content
+	checkpointMu.Unlock()
+	return nil
+}
+
+// LoadCheckpoint decodes the state saved under key into target. It reports
+// false when the task has no such checkpoint, e.g. on its first attempt.
+//
+// Example:
+//
+//	var state ImportState
+//	resumed, err := eywa.LoadCheckpoint("import", &state)
+//	if resumed {
+//	    eywa.Info("Resuming import", map[string]interface{}{"next": state.Next})
+//	}
+func LoadCheckpoint(key string, target interface{}) (bool, error) {
+	return loadCheckpoint(context.Background(), key, target)
+}
+
+// loadCheckpoint decodes the state saved under key for the task of ctx
+func loadCheckpoint(ctx context.Context, key string, target interface{}) (bool, error) {
+	euuid, cacheKey, err := checkpointUUID(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	checkpointMu.Lock()
+	content, cached := checkpointCache[cacheKey]
+	checkpointMu.Unlock()
+
+	if !cached {
+		exists, err := checkpointExists(ctx, euuid)
+		if err != nil {
+			return false, fmt.Errorf("cannot load checkpoint %s: %v", key, err)
+		}
+		if !exists {
+			return false, nil
+		}
+		if content, err = download(ctx, euuid, true); err != nil {
+			return false, fmt.Errorf("cannot load checkpoint %s: %v", key, err)
+		}
+
+		checkpointMu.Lock()
+		checkpointCache[cacheKey] = content
+		checkpointMu.Unlock()
+	}
+
+	if err := json.Unmarshal(content, target); err != nil {
+		return false, fmt.Errorf("cannot decode checkpoint %s: %v", key, err)
+	}
+	return true, nil
+}
+
+// ClearCheckpoint deletes the checkpoint saved under key, usually once the
+// work it tracks is complete
+func ClearCheckpoint(key string) error {
+	return clearCheckpoint(context.Background(), key)
+}
+
+// clearCheckpoint deletes the checkpoint saved under key for the task of ctx
+func clearCheckpoint(ctx context.Context, key string) error {
+	euuid, cacheKey, err := checkpointUUID(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	checkpointMu.Lock()
+	delete(checkpointCache, cacheKey)
+	checkpointMu.Unlock()
+
+	exists, err := checkpointExists(ctx, euuid)
+	if err != nil {
+		return fmt.Errorf("cannot clear checkpoint %s: %v", key, err)
+	}
+	if exists && !deleteFile(ctx, euuid, true) {
+		return fmt.Errorf("cannot clear checkpoint %s", key)
+	}
+	return nil
+}
+
+// checkpointUUID derives a stable file euuid from the euuid of the task of
+// ctx and key (a name based UUID in the style of version 5). cacheKey
+// identifies the checkpoint in checkpointCache.
+func checkpointUUID(ctx context.Context, key string) (euuid, cacheKey string, err error) {
+	if key == "" {
+		return "", "", fmt.Errorf("checkpoint key must not be empty")
+	}
+	task, err := currentTaskFor(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("checkpoint %s needs an active task: %v", key, err)
+	}
+
+	name := task.EUUID + "/" + key
+	sum := sha1.Sum([]byte("eywa-checkpoint/" + name))
+	b := sum[:16]
+	b[6] = (b[6] & 0x0f) | 0x50 // Version 5
+	b[8] = (b[8] & 0x3f) | 0x80 // Variant bits
+	return fmt.Sprintf("%08x-%04x-%04x-%04x-%012x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), name, nil
+}
+
+// checkpointFileName builds a readable file name from the key
+func checkpointFileName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, key)
+	return "checkpoint-" + name + ".json"
+}
+
+// checkpointExists looks up the checkpoint file
+func checkpointExists(ctx context.Context, euuid string) (bool, error) {
+	query := `
+		query CheckpointExists($uuid: UUID!) {
+			getFile(euuid: $uuid) {
+				euuid
+			}
+		}
+	`
+	result, err := graphQLContext(ctx, query, map[string]interface{}{
+		"uuid": euuid,
+	})
+	if err != nil {
+		return false, err
+	}
+	data, _ := result["data"].(map[string]interface{})
+	file, _ := data["getFile"].(map[string]interface{})
+	return file != nil, nil
+}
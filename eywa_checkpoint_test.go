@@ -0,0 +1,101 @@
+package eywa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fileStore fakes the EYWA file API and its S3 storage
+func fileStore(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	files := make(map[string][]byte)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if id := strings.TrimPrefix(r.URL.Path, "/s3/"); id != r.URL.Path {
+			if r.Method == http.MethodPut {
+				files[id], _ = io.ReadAll(r.Body)
+				return
+			}
+			w.Write(files[id])
+			return
+		}
+
+		var request GraphQLParams
+		json.NewDecoder(r.Body).Decode(&request)
+		file, _ := request.Variables["file"].(map[string]interface{})
+		uuid, _ := request.Variables["uuid"].(string)
+
+		var data map[string]interface{}
+		switch {
+		case strings.Contains(request.Query, "requestUploadURL"):
+			data = map[string]interface{}{"requestUploadURL": server.URL + "/s3/" + file["euuid"].(string)}
+		case strings.Contains(request.Query, "confirmFileUpload"):
+			data = map[string]interface{}{"confirmFileUpload": true}
+		case strings.Contains(request.Query, "getFile"):
+			data = map[string]interface{}{"getFile": nil}
+			if _, exists := files[uuid]; exists {
+				data["getFile"] = map[string]interface{}{"euuid": uuid}
+			}
+		case strings.Contains(request.Query, "requestDownloadURL"):
+			data = map[string]interface{}{"requestDownloadURL": server.URL + "/s3/" + file["euuid"].(string)}
+		case strings.Contains(request.Query, "deleteFile"):
+			delete(files, uuid)
+			data = map[string]interface{}{"deleteFile": true}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCheckpointsAreScopedToSessions(t *testing.T) {
+	server := fileStore(t)
+	var output bytes.Buffer
+	EnableDevMode(&DevOptions{GraphQLEndpoint: server.URL + "/graphql", Output: &output, NoColor: true})
+
+	first := newTaskSession(context.Background(), &Task{EUUID: "11111111-1111-4111-8111-111111111111"}, "robot")
+	second := newTaskSession(context.Background(), &Task{EUUID: "22222222-2222-4222-8222-222222222222"}, "robot")
+
+	if err := first.SaveCheckpoint("import", map[string]int{"next": 42}); err != nil {
+		t.Fatalf("SaveCheckpoint: %v", err)
+	}
+
+	var state map[string]int
+	if found, err := second.LoadCheckpoint("import", &state); err != nil || found {
+		t.Errorf("checkpoint of the first task visible to the second: found=%v err=%v", found, err)
+	}
+	if found, err := first.LoadCheckpoint("import", &state); err != nil || !found || state["next"] != 42 {
+		t.Errorf("LoadCheckpoint = %v, %v, %v", found, state, err)
+	}
+
+	// Bypass the cache to read the stored file back
+	checkpointMu.Lock()
+	checkpointCache = make(map[string][]byte)
+	checkpointMu.Unlock()
+	state = nil
+	if found, err := first.LoadCheckpoint("import", &state); err != nil || !found || state["next"] != 42 {
+		t.Errorf("LoadCheckpoint after cache reset = %v, %v, %v", found, state, err)
+	}
+
+	if err := first.ClearCheckpoint("import"); err != nil {
+		t.Fatalf("ClearCheckpoint: %v", err)
+	}
+	if found, _ := first.LoadCheckpoint("import", &state); found {
+		t.Error("checkpoint still found after ClearCheckpoint")
+	}
+
+	if strings.Contains(output.String(), "upload") || strings.Contains(output.String(), "Download") {
+		t.Errorf("checkpoint file transfers were logged:\n%s", output.String())
+	}
+}
@@ -0,0 +1,638 @@
+// EYWA Development Console for Go
+//
+// When a robot is started without an EYWA runtime attached (plain `go run`
+// in a terminal), JSON-RPC traffic is served locally instead:
+// - logs are rendered as colored, human-readable lines
+// - reports are rendered as markdown cards and text tables
+// - task.get is answered from a local JSON file
+// - GraphQL is forwarded to a configurable endpoint or answered from a stub file
+//
+// Dev mode is detected automatically when stdin is a terminal. The EYWA_DEV
+// environment variable forces it on ("1", "true") or off ("0", "false").
+
+package eywa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Environment variables read by dev mode
+const (
+	EnvDev             = "EYWA_DEV"
+	EnvTaskFile        = "EYWA_TASK_FILE"
+	EnvGraphQLEndpoint = "EYWA_GRAPHQL_ENDPOINT"
+	EnvGraphQLToken    = "EYWA_GRAPHQL_TOKEN"
+	EnvGraphQLStub     = "EYWA_GRAPHQL_STUB"
+)
+
+// DefaultTaskFile is the task file used in dev mode when none is configured
+const DefaultTaskFile = "task.json"
+
+// DevOptions configures the local development mode
+type DevOptions struct {
+	TaskFile        string    `json:"task_file,omitempty"`        // JSON file served for task.get
+	GraphQLEndpoint string    `json:"graphql_endpoint,omitempty"` // HTTP endpoint GraphQL is forwarded to
+	GraphQLToken    string    `json:"graphql_token,omitempty"`    // Bearer token for GraphQLEndpoint
+	GraphQLStubFile string    `json:"graphql_stub,omitempty"`     // JSON file with canned GraphQL responses
+	Output          io.Writer `json:"-"`                          // Console output, defaults to stderr
+	NoColor         bool      `json:"no_color,omitempty"`         // Disable ANSI colors
+}
+
+// LocalRuntime answers robot requests without EYWA: task.get is served
+// from a JSON file and GraphQL through LocalGraphQL. It backs dev mode and
+// the eywa-local command.
+type LocalRuntime struct {
+	TaskFile string        // JSON file served for task.get, defaults to DefaultTaskFile
+	GraphQL  *LocalGraphQL // GraphQL endpoint or stub
+}
+
+// devRuntime serves JSON-RPC traffic locally in dev mode
+type devRuntime struct {
+	console *Console
+	local   *LocalRuntime
+}
+
+var (
+	devOnce sync.Once
+	devMu   sync.Mutex
+	devMode *devRuntime
+)
+
+// EnableDevMode switches the client to local development mode regardless
+// of auto-detection. Passing nil uses the EYWA_* environment variables.
+func EnableDevMode(options *DevOptions) {
+	if options == nil {
+		options = devOptionsFromEnv()
+	}
+	devOnce.Do(func() {})
+
+	devMu.Lock()
+	defer devMu.Unlock()
+	devMode = newDevRuntime(options)
+}
+
+// IsDevMode reports whether the client runs without an EYWA runtime
+func IsDevMode() bool {
+	return activeDevRuntime() != nil
+}
+
+// activeDevRuntime returns the dev runtime, or nil when attached to EYWA
+func activeDevRuntime() *devRuntime {
+	devOnce.Do(func() {
+		if detectDevMode() {
+			devMu.Lock()
+			devMode = newDevRuntime(devOptionsFromEnv())
+			devMu.Unlock()
+		}
+	})
+
+	devMu.Lock()
+	defer devMu.Unlock()
+	return devMode
+}
+
+func detectDevMode() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(EnvDev))) {
+	case "1", "true", "yes", "on":
+		return true
+	case "0", "false", "no", "off":
+		return false
+	}
+	return IsTerminal(os.Stdin)
+}
+
+func devOptionsFromEnv() *DevOptions {
+	return &DevOptions{
+		TaskFile:        os.Getenv(EnvTaskFile),
+		GraphQLEndpoint: os.Getenv(EnvGraphQLEndpoint),
+		GraphQLToken:    os.Getenv(EnvGraphQLToken),
+		GraphQLStubFile: os.Getenv(EnvGraphQLStub),
+		NoColor:         os.Getenv("NO_COLOR") != "",
+	}
+}
+
+func newDevRuntime(options *DevOptions) *devRuntime {
+	out := options.Output
+	color := !options.NoColor
+	if out == nil {
+		out = os.Stderr
+		color = color && IsTerminal(os.Stderr)
+	}
+
+	return &devRuntime{
+		console: NewConsole(out, color),
+		local: &LocalRuntime{
+			TaskFile: options.TaskFile,
+			GraphQL: &LocalGraphQL{
+				Endpoint: options.GraphQLEndpoint,
+				Token:    options.GraphQLToken,
+				StubFile: options.GraphQLStubFile,
+			},
+		},
+	}
+}
+
+// IsTerminal reports whether f is an interactive terminal. Character
+// devices that are not terminals, like /dev/null, are not.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// notify renders an outgoing notification on the console
+func (d *devRuntime) notify(data map[string]interface{}) {
+	method, _ := data["method"].(string)
+	d.console.Message(method, data["params"])
+}
+
+// request answers an outgoing request locally
+func (d *devRuntime) request(data map[string]interface{}) Response {
+	method, _ := data["method"].(string)
+	return d.local.Handle(method, data["id"], data["params"])
+}
+
+// Handle answers the JSON-RPC request method with id and params
+func (r *LocalRuntime) Handle(method string, id interface{}, params interface{}) Response {
+	response := Response{
+		JsonRPC: "2.0",
+		ID:      fmt.Sprintf("%v", id),
+	}
+
+	switch method {
+	case "task.get":
+		taskFile := r.TaskFile
+		if taskFile == "" {
+			taskFile = DefaultTaskFile
+		}
+		task, err := readJSONFile(taskFile)
+		if err != nil {
+			response.Error = map[string]interface{}{
+				"message": fmt.Sprintf("cannot read task file: %v", err),
+			}
+		} else {
+			response.Result = task
+		}
+	case "eywa.datasets.graphql":
+		var graphQLParams GraphQLParams
+		if err := convertJSON(params, &graphQLParams); err != nil {
+			response.Error = map[string]interface{}{"message": err.Error()}
+			break
+		}
+		graphql := r.GraphQL
+		if graphql == nil {
+			graphql = &LocalGraphQL{}
+		}
+		result, err := graphql.Execute(graphQLParams.Query, graphQLParams.Variables)
+		if err != nil {
+			response.Error = map[string]interface{}{"message": err.Error()}
+		} else {
+			response.Result = result
+		}
+	default:
+		response.Error = map[string]interface{}{
+			"message": fmt.Sprintf("method %s is not available without an EYWA runtime", method),
+		}
+	}
+
+	return response
+}
+
+// Console renders EYWA protocol messages in human-readable form
+type Console struct {
+	mu    sync.Mutex
+	out   io.Writer
+	color bool
+}
+
+// NewConsole creates a console writing to out, optionally with ANSI colors
+func NewConsole(out io.Writer, color bool) *Console {
+	return &Console{
+		out:   out,
+		color: color,
+	}
+}
+
+// ANSI color codes used by the console
+const (
+	ansiReset   = "\033[0m"
+	ansiBold    = "\033[1m"
+	ansiDim     = "\033[2m"
+	ansiRed     = "\033[31m"
+	ansiGreen   = "\033[32m"
+	ansiYellow  = "\033[33m"
+	ansiBlue    = "\033[34m"
+	ansiMagenta = "\033[35m"
+	ansiCyan    = "\033[36m"
+	ansiGray    = "\033[90m"
+)
+
+var levelColors = map[string]string{
+	TRACE:         ansiGray,
+	DEBUG:         ansiBlue,
+	INFO:          ansiGreen,
+	WARN:          ansiYellow,
+	LOG_ERROR:     ansiRed,
+	LOG_EXCEPTION: ansiBold + ansiMagenta,
+}
+
+func (c *Console) paint(color, text string) string {
+	if !c.color || color == "" {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// Message renders any JSON-RPC notification by method name
+func (c *Console) Message(method string, params interface{}) {
+	switch method {
+	case "task.log":
+		var entry LogParams
+		if err := convertJSON(params, &entry); err == nil {
+			c.Log(entry)
+			return
+		}
+	case "task.report":
+		var report ReportParams
+		if err := convertJSON(params, &report); err == nil {
+			c.Report(report)
+			return
+		}
+	case "task.update", "task.close", "task.return":
+		var task TaskParams
+		if params == nil || convertJSON(params, &task) == nil {
+			c.Task(method, task)
+			return
+		}
+	}
+
+	c.write(fmt.Sprintf("%s %s\n", c.paint(ansiCyan, "→ "+method), c.paint(ansiDim, compactJSON(params))))
+}
+
+// Log renders a single log entry
+func (c *Console) Log(params LogParams) {
+	timestamp := time.Now()
+	if params.Time != nil {
+		timestamp = *params.Time
+	}
+
+	var line strings.Builder
+	line.WriteString(c.paint(ansiGray, timestamp.Local().Format("15:04:05.000")))
+	line.WriteString(" ")
+	line.WriteString(c.paint(levelColors[params.Event], fmt.Sprintf("%-9s", params.Event)))
+	line.WriteString(" ")
+	if location := formatCoordinates(params.Coordinates); location != "" {
+		line.WriteString(c.paint(ansiCyan, "["+location+"]"))
+		line.WriteString(" ")
+	}
+	line.WriteString(params.Message)
+	if params.Duration != nil {
+		line.WriteString(c.paint(ansiGray, fmt.Sprintf(" (%dms)", *params.Duration)))
+	}
+	if params.Data != nil {
+		line.WriteString(" ")
+		line.WriteString(c.paint(ansiDim, compactJSON(params.Data)))
+	}
+	line.WriteString("\n")
+
+	c.write(line.String())
+}
+
+// Report renders a task report with its markdown card and tables
+func (c *Console) Report(params ReportParams) {
+	var out strings.Builder
+	out.WriteString("\n")
+	out.WriteString(c.paint(ansiBold+ansiCyan, "━━ REPORT: "+params.Message))
+	out.WriteString("\n")
+
+	var data ReportData
+	if params.Data != nil && convertJSON(params.Data, &data) == nil {
+		if card := strings.TrimSpace(data.Card); card != "" {
+			for _, line := range strings.Split(card, "\n") {
+				out.WriteString("  ")
+				out.WriteString(c.renderMarkdownLine(line))
+				out.WriteString("\n")
+			}
+		}
+
+		names := make([]string, 0, len(data.Tables))
+		for name := range data.Tables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			out.WriteString("\n  ")
+			out.WriteString(c.paint(ansiBold, name))
+			out.WriteString("\n")
+			out.WriteString(formatTextTable(data.Tables[name], "  "))
+		}
+	}
+
+	if params.HasImage {
+		out.WriteString(c.paint(ansiGray, fmt.Sprintf("  [image: %d bytes base64]", len(params.Image))))
+		out.WriteString("\n")
+	}
+	out.WriteString("\n")
+
+	c.write(out.String())
+}
+
+// Task renders task status changes
+func (c *Console) Task(method string, params TaskParams) {
+	status := params.Status
+	color := ansiCyan
+	switch status {
+	case SUCCESS:
+		color = ansiGreen
+	case ERROR, EXCEPTION:
+		color = ansiRed
+	}
+
+	switch method {
+	case "task.close":
+		c.write(c.paint(ansiBold+color, fmt.Sprintf("■ Task closed: %s", status)) + "\n")
+		if params.Result != nil {
+			c.write(fmt.Sprintf("  result: %s\n", compactJSON(params.Result)))
+		}
+		if len(params.Files) > 0 {
+			c.write(fmt.Sprintf("  files: %s\n", strings.Join(params.Files, ", ")))
+		}
+	case "task.return":
+		c.write(c.paint(ansiBold+ansiCyan, "■ Task returned to EYWA") + "\n")
+		if params.Message != "" {
+			c.write(fmt.Sprintf("  message: %s\n", params.Message))
+		}
+		if params.ResumeAt != nil {
+			c.write(fmt.Sprintf("  resume at: %s\n", params.ResumeAt.Local().Format(time.RFC3339)))
+		}
+		if params.Condition != "" {
+			c.write(fmt.Sprintf("  resume on: %s\n", params.Condition))
+		}
+	default:
+		text := "● Task"
+		if status != "" {
+			text += " status: " + string(status)
+		}
+		if params.Message != "" {
+			text += " – " + params.Message
+		}
+		if params.Progress != nil {
+			text += fmt.Sprintf(" (%.0f%%)", *params.Progress)
+		}
+		if params.Data != nil {
+			text += " " + compactJSON(params.Data)
+		}
+		c.write(c.paint(color, text) + "\n")
+	}
+}
+
+func (c *Console) write(text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	io.WriteString(c.out, text)
+}
+
+var markdownEmphasis = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+
+// renderMarkdownLine highlights headings and bold text of a markdown card
+func (c *Console) renderMarkdownLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "#") {
+		return c.paint(ansiBold+ansiCyan, strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+	}
+	if !c.color {
+		return line
+	}
+	return markdownEmphasis.ReplaceAllString(line, ansiBold+"$1"+ansiReset)
+}
+
+// formatTextTable renders a table with aligned, boxed columns
+func formatTextTable(table TableData, indent string) string {
+	widths := make([]int, len(table.Headers))
+	cells := make([][]string, len(table.Rows))
+
+	for i, header := range table.Headers {
+		widths[i] = utf8.RuneCountInString(header)
+	}
+	for i, row := range table.Rows {
+		cells[i] = make([]string, len(row))
+		for j, cell := range row {
+			text := formatCell(cell)
+			cells[i][j] = text
+			if j < len(widths) && utf8.RuneCountInString(text) > widths[j] {
+				widths[j] = utf8.RuneCountInString(text)
+			}
+		}
+	}
+
+	var separator strings.Builder
+	separator.WriteString(indent + "+")
+	for _, width := range widths {
+		separator.WriteString(strings.Repeat("-", width+2) + "+")
+	}
+	separator.WriteString("\n")
+
+	formatRow := func(values []string) string {
+		var row strings.Builder
+		row.WriteString(indent + "|")
+		for i, width := range widths {
+			value := ""
+			if i < len(values) {
+				value = values[i]
+			}
+			row.WriteString(" " + value + strings.Repeat(" ", width-utf8.RuneCountInString(value)) + " |")
+		}
+		row.WriteString("\n")
+		return row.String()
+	}
+
+	var out strings.Builder
+	out.WriteString(separator.String())
+	out.WriteString(formatRow(table.Headers))
+	out.WriteString(separator.String())
+	for _, row := range cells {
+		out.WriteString(formatRow(row))
+	}
+	out.WriteString(separator.String())
+	return out.String()
+}
+
+// formatCoordinates renders workflow coordinates as "stage › step › item"
+func formatCoordinates(coordinates interface{}) string {
+	if coordinates == nil {
+		return ""
+	}
+	var typed Coordinates
+	if convertJSON(coordinates, &typed) != nil {
+		return compactJSON(coordinates)
+	}
+
+	var parts []string
+	for _, part := range []string{typed.Stage, typed.Step, typed.Item} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if typed.Index != nil {
+		parts = append(parts, fmt.Sprintf("#%d", *typed.Index))
+	}
+	if typed.Geo != nil {
+		parts = append(parts, fmt.Sprintf("%.5f,%.5f", typed.Geo.Latitude, typed.Geo.Longitude))
+	}
+	return strings.Join(parts, " › ")
+}
+
+func formatCell(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.ReplaceAll(v, "\n", " ")
+	case float64, float32, int, int64, int32, uint, uint64, uint32, bool:
+		return fmt.Sprintf("%v", v)
+	}
+	return compactJSON(cell)
+}
+
+// LocalGraphQL answers GraphQL queries outside the EYWA runtime, either by
+// forwarding them to an HTTP endpoint or from a stub file.
+//
+// A stub file is a JSON object. Responses are looked up by operation name
+// (query GetUsers {...} -> "GetUsers"), then by the first selected field
+// ({ searchUser {...} } -> "searchUser"). A stub that itself contains
+// "data" or "errors" is returned for every query.
+type LocalGraphQL struct {
+	Endpoint string
+	Token    string
+	StubFile string
+	Client   *http.Client
+}
+
+var (
+	graphqlOperationName = regexp.MustCompile(`^\s*(?:query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+	graphqlFirstField    = regexp.MustCompile(`\{\s*([_A-Za-z][_0-9A-Za-z]*)`)
+)
+
+// Execute runs a GraphQL query and returns the decoded GraphQL response
+func (g *LocalGraphQL) Execute(query string, variables map[string]interface{}) (map[string]interface{}, error) {
+	if g.Endpoint != "" {
+		return g.forward(query, variables)
+	}
+	if g.StubFile != "" {
+		return g.stub(query)
+	}
+	return nil, fmt.Errorf("GraphQL is not available in dev mode: set %s or %s", EnvGraphQLEndpoint, EnvGraphQLStub)
+}
+
+func (g *LocalGraphQL) forward(query string, variables map[string]interface{}) (map[string]interface{}, error) {
+	body, err := json.Marshal(GraphQLParams{
+		Query:     query,
+		Variables: variables,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", g.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+
+	client := g.Client
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(content))
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, fmt.Errorf("invalid GraphQL response: %v", err)
+	}
+	return result, nil
+}
+
+func (g *LocalGraphQL) stub(query string) (map[string]interface{}, error) {
+	content, err := readJSONFile(g.StubFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read GraphQL stub file: %v", err)
+	}
+
+	stubs, ok := content.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("GraphQL stub file must contain a JSON object")
+	}
+
+	for _, pattern := range []*regexp.Regexp{graphqlOperationName, graphqlFirstField} {
+		if match := pattern.FindStringSubmatch(query); match != nil {
+			if response, ok := stubs[match[1]].(map[string]interface{}); ok {
+				return response, nil
+			}
+		}
+	}
+
+	_, hasData := stubs["data"]
+	_, hasErrors := stubs["errors"]
+	if hasData || hasErrors {
+		return stubs, nil
+	}
+
+	return nil, fmt.Errorf("no GraphQL stub matches query")
+}
+
+// Helper functions
+
+func readJSONFile(path string) (interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(content, &value); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return value, nil
+}
+
+// convertJSON converts value into target through its JSON encoding
+func convertJSON(value interface{}, target interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, target)
+}
+
+func compactJSON(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,174 @@
+// EYWA Task Continuations for Go
+//
+// A robot that has to wait, for an approval or for a file that is not
+// there yet, returns its task to EYWA instead of blocking. ReturnTaskWith
+// saves a continuation payload with the task, tells EYWA when or on what
+// condition to resume it, and exits. When EYWA starts the robot again for
+// the same task, IsResumption and LoadContinuation pick up where it left
+// off. The continuation is stored as a checkpoint, see SaveCheckpoint, and
+// is cleared when the task that loaded it closes with SUCCESS.
+
+package eywa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Checkpoint key of the continuation, reserved for ReturnTaskWith
+const continuationKey = "eywa.continuation"
+
+// ReturnOptions configures how EYWA resumes a returned task
+type ReturnOptions struct {
+	Message     string        `json:"message,omitempty"`      // Task message while the task waits
+	ResumeAt    *time.Time    `json:"resume_at,omitempty"`    // Resume the task at this time
+	ResumeAfter time.Duration `json:"resume_after,omitempty"` // Resume the task after this delay, used when ResumeAt is nil
+	Condition   string        `json:"condition,omitempty"`    // Condition EYWA waits for, e.g. "approval:invoice-42"
+}
+
+// Continuation is the state a task was returned with
+type Continuation struct {
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	ReturnedAt time.Time       `json:"returned_at"`
+	ResumeAt   *time.Time      `json:"resume_at,omitempty"`
+	Condition  string          `json:"condition,omitempty"`
+	Returns    int             `json:"returns"` // How many times the task was returned
+}
+
+// ReturnTaskWith saves payload as the continuation of the current task,
+// returns the task to EYWA with the resume time or condition and exits.
+// If the continuation cannot be saved the task is not returned and the
+// error is returned instead.
+//
+// Example:
+//
+//	eywa.ReturnTaskWith(ApprovalState{Invoice: id, Step: "approve"}, &eywa.ReturnOptions{
+//	    Message:     "Waiting for approval",
+//	    Condition:   "approval:" + id,
+//	    ResumeAfter: 24 * time.Hour,
+//	})
+func ReturnTaskWith(payload interface{}, options *ReturnOptions) error {
+	if lifecycle.isClosed() {
+		return ErrTaskClosed
+	}
+	opts := ReturnOptions{}
+	if options != nil {
+		opts = *options
+	}
+
+	now := time.Now().UTC()
+	var resumeAt *time.Time
+	switch {
+	case opts.ResumeAt != nil:
+		at := opts.ResumeAt.UTC()
+		resumeAt = &at
+	case opts.ResumeAfter > 0:
+		at := now.Add(opts.ResumeAfter)
+		resumeAt = &at
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("cannot encode continuation: %v", err)
+	}
+
+	continuation := Continuation{
+		Payload:    encoded,
+		ReturnedAt: now,
+		ResumeAt:   resumeAt,
+		Condition:  opts.Condition,
+		Returns:    1,
+	}
+	var previous Continuation
+	found, err := LoadCheckpoint(continuationKey, &previous)
+	if err != nil {
+		return fmt.Errorf("cannot load continuation: %v", err)
+	}
+	if found {
+		continuation.Returns = previous.Returns + 1
+	}
+	if err := SaveCheckpoint(continuationKey, continuation); err != nil {
+		return fmt.Errorf("cannot save continuation: %v", err)
+	}
+
+	uploadLogCapture()
+	SendNotification(map[string]interface{}{
+		"method": "task.return",
+		"params": TaskParams{
+			Message:   opts.Message,
+			ResumeAt:  resumeAt,
+			Condition: opts.Condition,
+		},
+	})
+	os.Exit(0)
+	return nil
+}
+
+// IsResumption reports whether the current task was returned with
+// ReturnTaskWith before, i.e. this run continues it
+func IsResumption() (bool, error) {
+	continuation, err := LoadContinuation(nil)
+	return continuation != nil, err
+}
+
+// LoadContinuation returns the continuation of the current task and
+// decodes its payload into target, unless target is nil. It returns nil
+// when the task was never returned.
+//
+// Example:
+//
+//	var state ApprovalState
+//	continuation, err := eywa.LoadContinuation(&state)
+//	if err != nil {
+//	    return err
+//	}
+//	if continuation != nil {
+//	    eywa.Info("Resuming after approval", map[string]interface{}{"invoice": state.Invoice})
+//	}
+func LoadContinuation(target interface{}) (*Continuation, error) {
+	var continuation Continuation
+	found, err := LoadCheckpoint(continuationKey, &continuation)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load continuation: %v", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	if target != nil && len(continuation.Payload) > 0 {
+		if err := json.Unmarshal(continuation.Payload, target); err != nil {
+			return nil, fmt.Errorf("cannot decode continuation payload: %v", err)
+		}
+	}
+	return &continuation, nil
+}
+
+// ClearContinuation deletes the continuation once the task no longer needs
+// it, so a later retry starts from the beginning. Closing the task with
+// SUCCESS does this for a continuation loaded by LoadContinuation.
+func ClearContinuation() error {
+	return ClearCheckpoint(continuationKey)
+}
+
+// clearLoadedContinuation deletes the continuation of the task of ctx if
+// this process loaded or saved it. Tasks that never used a continuation
+// skip the lookup.
+func clearLoadedContinuation(ctx context.Context) {
+	_, cacheKey, err := checkpointUUID(ctx, continuationKey)
+	if err != nil {
+		return
+	}
+	checkpointMu.Lock()
+	_, loaded := checkpointCache[cacheKey]
+	checkpointMu.Unlock()
+	if !loaded {
+		return
+	}
+
+	if err := clearCheckpoint(ctx, continuationKey); err != nil {
+		WithContext(ctx).LogErr(WARN, "Cannot clear continuation", err)
+	}
+}
@@ -0,0 +1,57 @@
+package eywa
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestSuccessfulCloseClearsLoadedContinuation(t *testing.T) {
+	server := fileStore(t)
+	var output bytes.Buffer
+	EnableDevMode(&DevOptions{GraphQLEndpoint: server.URL + "/graphql", Output: &output, NoColor: true})
+
+	tests := []struct {
+		name    string
+		euuid   string
+		status  Status
+		cleared bool
+	}{
+		{"success", "33333333-3333-4333-8333-333333333333", SUCCESS, true},
+		{"error", "44444444-4444-4444-8444-444444444444", ERROR, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{EUUID: tt.euuid}
+			returned := newTaskSession(context.Background(), task, "robot")
+			if err := returned.SaveCheckpoint(continuationKey, Continuation{Returns: 1}); err != nil {
+				t.Fatalf("SaveCheckpoint: %v", err)
+			}
+
+			// A new run of the task loads the continuation and closes
+			checkpointMu.Lock()
+			checkpointCache = make(map[string][]byte)
+			checkpointMu.Unlock()
+			resumed := newTaskSession(context.Background(), task, "robot")
+			var continuation Continuation
+			if found, err := resumed.LoadCheckpoint(continuationKey, &continuation); err != nil || !found {
+				t.Fatalf("LoadCheckpoint = %v, %v", found, err)
+			}
+			if err := resumed.Close(tt.status); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			checkpointMu.Lock()
+			checkpointCache = make(map[string][]byte)
+			checkpointMu.Unlock()
+			next := newTaskSession(context.Background(), task, "robot")
+			found, err := next.LoadCheckpoint(continuationKey, &continuation)
+			if err != nil {
+				t.Fatalf("LoadCheckpoint: %v", err)
+			}
+			if found == tt.cleared {
+				t.Errorf("continuation found=%v after closing with %s:\n%s", found, tt.status, output.String())
+			}
+		})
+	}
+}
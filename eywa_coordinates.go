@@ -0,0 +1,205 @@
+// EYWA Log Coordinates for Go
+//
+// Coordinates describe where in a robot's workflow a log entry occurred
+// (stage, step, item) and optionally where in the world (geographic point),
+// so EYWA can group and filter logs by them.
+
+package eywa
+
+import (
+	"fmt"
+	"math"
+	"unicode"
+)
+
+// maxCoordinateLength bounds stage, step and item identifiers
+const maxCoordinateLength = 256
+
+// Coordinates locate a log entry within the robot's workflow
+type Coordinates struct {
+	Stage string    `json:"stage,omitempty"`
+	Step  string    `json:"step,omitempty"`
+	Item  string    `json:"item,omitempty"`
+	Index *int      `json:"index,omitempty"`
+	Geo   *GeoPoint `json:"geo,omitempty"`
+}
+
+// GeoPoint is a WGS 84 geographic position
+type GeoPoint struct {
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Altitude  *float64 `json:"altitude,omitempty"`
+}
+
+// NewCoordinates creates coordinates for a stage and step of the workflow.
+// Either may be empty.
+//
+// Example:
+//
+//	coords := eywa.NewCoordinates("import", "parse-invoices").WithItem(invoice.Number)
+//	eywa.Log(eywa.INFO, "Invoice parsed", nil, nil, coords, nil)
+func NewCoordinates(stage, step string) *Coordinates {
+	return &Coordinates{
+		Stage: stage,
+		Step:  step,
+	}
+}
+
+// WithStage returns a copy of the coordinates with the stage set
+func (c *Coordinates) WithStage(stage string) *Coordinates {
+	clone := c.clone()
+	clone.Stage = stage
+	return clone
+}
+
+// WithStep returns a copy of the coordinates with the step set
+func (c *Coordinates) WithStep(step string) *Coordinates {
+	clone := c.clone()
+	clone.Step = step
+	return clone
+}
+
+// WithItem returns a copy of the coordinates with the item identifier set
+func (c *Coordinates) WithItem(item string) *Coordinates {
+	clone := c.clone()
+	clone.Item = item
+	return clone
+}
+
+// WithIndex returns a copy of the coordinates with the item position set
+func (c *Coordinates) WithIndex(index int) *Coordinates {
+	clone := c.clone()
+	clone.Index = &index
+	return clone
+}
+
+// WithGeo returns a copy of the coordinates with a geographic point set
+func (c *Coordinates) WithGeo(point *GeoPoint) *Coordinates {
+	clone := c.clone()
+	clone.Geo = point
+	return clone
+}
+
+// Validate checks identifiers, index and geographic point
+func (c *Coordinates) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.Stage == "" && c.Step == "" && c.Item == "" && c.Index == nil && c.Geo == nil {
+		return fmt.Errorf("coordinates must set at least one field")
+	}
+
+	for _, field := range []struct{ name, value string }{
+		{"stage", c.Stage},
+		{"step", c.Step},
+		{"item", c.Item},
+	} {
+		if err := validateCoordinateID(field.name, field.value); err != nil {
+			return err
+		}
+	}
+
+	if c.Index != nil && *c.Index < 0 {
+		return fmt.Errorf("coordinate index must not be negative: %d", *c.Index)
+	}
+
+	if c.Geo != nil {
+		return c.Geo.Validate()
+	}
+	return nil
+}
+
+func (c *Coordinates) clone() *Coordinates {
+	if c == nil {
+		return &Coordinates{}
+	}
+	clone := *c
+	return &clone
+}
+
+// merge overlays the non-empty fields of other onto a copy of c
+func (c *Coordinates) merge(other *Coordinates) *Coordinates {
+	if other == nil {
+		return c
+	}
+	merged := c.clone()
+	if other.Stage != "" {
+		merged.Stage = other.Stage
+	}
+	if other.Step != "" {
+		merged.Step = other.Step
+	}
+	if other.Item != "" {
+		merged.Item = other.Item
+	}
+	if other.Index != nil {
+		merged.Index = other.Index
+	}
+	if other.Geo != nil {
+		merged.Geo = other.Geo
+	}
+	return merged
+}
+
+// NewGeoPoint creates a validated geographic point
+func NewGeoPoint(latitude, longitude float64) (*GeoPoint, error) {
+	point := &GeoPoint{
+		Latitude:  latitude,
+		Longitude: longitude,
+	}
+	if err := point.Validate(); err != nil {
+		return nil, err
+	}
+	return point, nil
+}
+
+// Validate checks that latitude and longitude are within range
+func (p *GeoPoint) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if math.IsNaN(p.Latitude) || p.Latitude < -90 || p.Latitude > 90 {
+		return fmt.Errorf("latitude must be between -90 and 90: %v", p.Latitude)
+	}
+	if math.IsNaN(p.Longitude) || p.Longitude < -180 || p.Longitude > 180 {
+		return fmt.Errorf("longitude must be between -180 and 180: %v", p.Longitude)
+	}
+	if p.Altitude != nil && (math.IsNaN(*p.Altitude) || math.IsInf(*p.Altitude, 0)) {
+		return fmt.Errorf("altitude must be a finite number")
+	}
+	return nil
+}
+
+func validateCoordinateID(name, value string) error {
+	if len(value) > maxCoordinateLength {
+		return fmt.Errorf("coordinate %s exceeds %d bytes", name, maxCoordinateLength)
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("coordinate %s contains control characters", name)
+		}
+	}
+	return nil
+}
+
+// validateLogCoordinates drops typed coordinates that fail validation.
+// Untyped coordinates are passed through unchanged.
+func validateLogCoordinates(coordinates interface{}) (interface{}, error) {
+	var typed *Coordinates
+	switch c := coordinates.(type) {
+	case *Coordinates:
+		if c == nil {
+			return nil, nil
+		}
+		typed = c
+	case Coordinates:
+		typed = &c
+	default:
+		return coordinates, nil
+	}
+
+	if err := typed.Validate(); err != nil {
+		return nil, err
+	}
+	return typed, nil
+}
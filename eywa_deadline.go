@@ -0,0 +1,150 @@
+// EYWA Task Deadlines for Go
+//
+// A task may carry its expected maximum runtime in its data, either as an
+// absolute "deadline" (RFC 3339 or unix timestamp) or as a relative
+// "timeout" (seconds or a Go duration like "15m"). Run derives a context
+// deadline from it, or from RunOptions.Timeout, warns as the deadline
+// approaches and closes the task with ERROR once it is exceeded.
+
+package eywa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrTaskDeadlineExceeded is logged and reported when a task runs past its deadline
+var ErrTaskDeadlineExceeded = errors.New("task deadline exceeded")
+
+// Fractions of the available time after which an approaching deadline is logged
+var deadlineWarnings = []float64{0.75, 0.9}
+
+// TaskDeadline returns the deadline set in the current task data. A
+// relative timeout counts from the task start, or from now when the start
+// is unknown.
+func TaskDeadline() (time.Time, bool, error) {
+	task, err := CurrentTask()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return deadlineFromTask(task, time.Now())
+}
+
+func deadlineFromTask(task *Task, now time.Time) (time.Time, bool, error) {
+	if value, exists := task.Data["deadline"]; exists && value != nil {
+		deadline := parseTaskTime(value)
+		if deadline == nil {
+			return time.Time{}, false, fmt.Errorf("invalid task deadline: %v", value)
+		}
+		return *deadline, true, nil
+	}
+
+	if value, exists := task.Data["timeout"]; exists && value != nil {
+		timeout, err := parseTaskTimeout(value)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		start := now
+		if task.Started != nil {
+			start = *task.Started
+		}
+		return start.Add(timeout), true, nil
+	}
+	return time.Time{}, false, nil
+}
+
+// parseTaskTimeout accepts seconds as a number or numeric string, and Go durations
+func parseTaskTimeout(value interface{}) (time.Duration, error) {
+	var timeout time.Duration
+	switch v := value.(type) {
+	case float64:
+		timeout = time.Duration(v * float64(time.Second))
+	case string:
+		if seconds, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			timeout = time.Duration(seconds * float64(time.Second))
+		} else if parsed, err := time.ParseDuration(strings.TrimSpace(v)); err == nil {
+			timeout = parsed
+		} else {
+			return 0, fmt.Errorf("invalid task timeout: %q", v)
+		}
+	default:
+		return 0, fmt.Errorf("invalid task timeout: %v", value)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("task timeout must be positive: %v", value)
+	}
+	return timeout, nil
+}
+
+// runDeadline picks the task deadline, falling back to the robot-level
+// timeout. Problems reading the task are logged and ignored.
+func runDeadline(opts RunOptions, start time.Time) (time.Time, bool) {
+	task, err := CurrentTask()
+	if err == nil {
+		deadline, ok, err := deadlineFromTask(task, start)
+		if err != nil {
+			LogErr(WARN, "Ignoring task deadline", err)
+		} else if ok {
+			return deadline, true
+		}
+	}
+	if opts.Timeout > 0 {
+		return start.Add(opts.Timeout), true
+	}
+	return time.Time{}, false
+}
+
+// watchDeadline logs warnings as the deadline approaches until ctx is done
+func watchDeadline(ctx context.Context, start, deadline time.Time) {
+	total := deadline.Sub(start)
+	for _, fraction := range deadlineWarnings {
+		at := start.Add(time.Duration(float64(total) * fraction))
+		wait := time.Until(at)
+		if wait <= 0 {
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		remaining := time.Until(deadline).Round(time.Second)
+		if remaining < 10*time.Second {
+			remaining = time.Until(deadline).Round(100 * time.Millisecond)
+		}
+		Warn(fmt.Sprintf("Task deadline approaching, %v left", remaining), map[string]interface{}{
+			"deadline":     deadline.Format(time.RFC3339),
+			"remaining_ms": remaining.Milliseconds(),
+		})
+	}
+}
+
+// stopOnDeadline waits for the cancelled robot within the grace period and
+// closes the task with ERROR
+func stopOnDeadline(deadline time.Time, done <-chan error, opts RunOptions) {
+	Error(fmt.Sprintf("Task deadline exceeded at %s, stopping robot", deadline.Format(time.RFC3339)), map[string]interface{}{
+		"error":    ErrTaskDeadlineExceeded.Error(),
+		"deadline": deadline.Format(time.RFC3339),
+	})
+
+	timer := time.NewTimer(opts.GracePeriod)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		Error(fmt.Sprintf("Robot did not stop within %v after the deadline", opts.GracePeriod), nil)
+	}
+
+	CloseTaskWithResult(ERROR, map[string]interface{}{
+		"error":    ErrTaskDeadlineExceeded.Error(),
+		"deadline": deadline.Format(time.RFC3339),
+	})
+}
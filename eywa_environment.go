@@ -0,0 +1,236 @@
+// EYWA Environment Reports for Go
+//
+// "Works on one worker, fails on another" is easier to debug when every
+// task shows where it ran. ReportEnvironment sends a task report with the
+// Go runtime, host, memory, build information and selected environment
+// variables. RunOptions.Environment sends it when the robot starts.
+// Variables whose names match a sensitive key pattern, or one of the
+// stricter environment patterns, are masked; other values pass through the
+// usual redaction.
+
+package eywa
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultEnvironmentVariables are reported when EnvironmentOptions.Variables is empty
+var DefaultEnvironmentVariables = []string{"EYWA_*", "GOMAXPROCS", "GOGC", "GOMEMLIMIT", "GODEBUG", "TZ", "LANG"}
+
+// sensitiveVariablePatterns mask environment variables on top of the
+// redaction key patterns. They match anywhere in the name, so EYWA_API_KEY,
+// DB_PASS and APITOKEN are masked, which whole key segments would let through.
+var sensitiveVariablePatterns = []string{"key", "pass", "credential", "private", "token", "secret", "auth"}
+
+// EnvironmentOptions configures ReportEnvironment
+type EnvironmentOptions struct {
+	Message      string   `json:"message,omitempty"`      // Report message, defaults to "Environment"
+	Variables    []string `json:"variables,omitempty"`    // Names of reported variables, a trailing * matches a prefix
+	Dependencies bool     `json:"dependencies,omitempty"` // Include the versions of all module dependencies
+}
+
+// ReportEnvironment reports the runtime, host, memory, build and selected
+// environment variables of the robot process
+//
+// Example:
+//
+//	eywa.ReportEnvironment(&eywa.EnvironmentOptions{
+//	    Variables:    []string{"EYWA_*", "HTTP_PROXY", "DB_*"},
+//	    Dependencies: true,
+//	})
+func ReportEnvironment(options *EnvironmentOptions) error {
+	opts := EnvironmentOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Message == "" {
+		opts.Message = "Environment"
+	}
+	if len(opts.Variables) == 0 {
+		opts.Variables = DefaultEnvironmentVariables
+	}
+
+	hostname, _ := os.Hostname()
+	tables := map[string]TableData{
+		"Runtime": propertyTable([][2]interface{}{
+			{"Library version", Version},
+			{"Go version", runtime.Version()},
+			{"OS/Arch", runtime.GOOS + "/" + runtime.GOARCH},
+			{"CPUs", runtime.NumCPU()},
+			{"GOMAXPROCS", runtime.GOMAXPROCS(0)},
+			{"Goroutines", runtime.NumGoroutine()},
+			{"Hostname", hostname},
+			{"PID", os.Getpid()},
+		}),
+		"Memory": memoryTable(),
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		tables["Build"] = buildTable(info)
+		if opts.Dependencies && len(info.Deps) > 0 {
+			tables["Dependencies"] = dependencyTable(info)
+		}
+	}
+	if variables := environmentTable(opts.Variables); len(variables.Rows) > 0 {
+		tables["Environment Variables"] = variables
+	}
+
+	card := fmt.Sprintf("## %s\n\n**%s** on **%s/%s** with %d CPUs, eywa-go %s",
+		opts.Message, hostname, runtime.GOOS, runtime.GOARCH, runtime.NumCPU(), Version)
+
+	return Report(opts.Message, &ReportOptions{
+		Data: &ReportData{
+			Card:   card,
+			Tables: tables,
+		},
+	})
+}
+
+func propertyTable(properties [][2]interface{}) TableData {
+	table := TableData{Headers: []string{"Property", "Value"}}
+	for _, property := range properties {
+		table.Rows = append(table.Rows, []interface{}{property[0], property[1]})
+	}
+	return table
+}
+
+// memoryTable reports the memory of the Go process and, where the host
+// exposes it, of the machine
+func memoryTable() TableData {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	properties := [][2]interface{}{
+		{"Heap in use", formatBytes(int64(stats.HeapInuse))},
+		{"Obtained from OS", formatBytes(int64(stats.Sys))},
+		{"GC cycles", stats.NumGC},
+	}
+	if total, available, ok := hostMemory(); ok {
+		properties = append(properties,
+			[2]interface{}{"Host total", formatBytes(total)},
+			[2]interface{}{"Host available", formatBytes(available)},
+		)
+	}
+	return propertyTable(properties)
+}
+
+// hostMemory reads total and available memory from /proc/meminfo on Linux
+func hostMemory() (int64, int64, bool) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, 0, false
+	}
+	defer file.Close()
+
+	var total, available int64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total = kb * 1024
+		case "MemAvailable:":
+			available = kb * 1024
+		}
+	}
+	return total, available, total > 0
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. "1.5 GiB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// buildTable reports the main module and the version control settings
+func buildTable(info *debug.BuildInfo) TableData {
+	properties := [][2]interface{}{
+		{"Module", info.Main.Path},
+		{"Module version", info.Main.Version},
+		{"Built with", info.GoVersion},
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs", "vcs.revision", "vcs.time", "vcs.modified", "GOOS", "GOARCH", "CGO_ENABLED":
+			properties = append(properties, [2]interface{}{setting.Key, setting.Value})
+		}
+	}
+	return propertyTable(properties)
+}
+
+func dependencyTable(info *debug.BuildInfo) TableData {
+	table := TableData{Headers: []string{"Module", "Version", "Replaced by"}}
+	for _, dep := range info.Deps {
+		replaced := ""
+		if dep.Replace != nil {
+			replaced = strings.TrimSpace(dep.Replace.Path + " " + dep.Replace.Version)
+		}
+		table.Rows = append(table.Rows, []interface{}{dep.Path, dep.Version, replaced})
+	}
+	return table
+}
+
+// environmentTable lists the set variables matching names. Values of
+// variables with sensitive names are masked, see isSensitiveVariable.
+func environmentTable(names []string) TableData {
+	table := TableData{Headers: []string{"Variable", "Value"}}
+
+	var matched []string
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		for _, pattern := range names {
+			if pattern == name || (strings.HasSuffix(pattern, "*") && strings.HasPrefix(name, strings.TrimSuffix(pattern, "*"))) {
+				matched = append(matched, name)
+				break
+			}
+		}
+	}
+	sort.Strings(matched)
+
+	for _, name := range matched {
+		var value interface{} = os.Getenv(name)
+		if isSensitiveVariable(name) {
+			value = maskValue(value)
+		}
+		table.Rows = append(table.Rows, []interface{}{name, value})
+	}
+	return table
+}
+
+// isSensitiveVariable reports whether the value of the environment variable
+// name must be masked. The environment patterns apply even when redaction
+// is disabled.
+func isSensitiveVariable(name string) bool {
+	if isSensitiveKey(name) {
+		return true
+	}
+	lower := strings.ToLower(name)
+	for _, pattern := range sensitiveVariablePatterns {
+		if strings.Contains(lower, pattern) {
+			return true
+		}
+	}
+	return false
+}
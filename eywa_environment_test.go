@@ -0,0 +1,28 @@
+package eywa
+
+import "testing"
+
+func TestEnvironmentTableMasksSecrets(t *testing.T) {
+	variables := map[string]bool{
+		"EYWA_TEST_GRAPHQL_TOKEN":    true,
+		"EYWA_TEST_API_KEY":          true,
+		"EYWA_TEST_DB_PASS":          true,
+		"EYWA_TEST_CREDENTIALS":      true,
+		"EYWA_TEST_PRIVATE_PEM":      true,
+		"EYWA_TEST_GRAPHQL_ENDPOINT": false,
+	}
+	for name := range variables {
+		t.Setenv(name, "s3cr3t-value")
+	}
+
+	table := environmentTable([]string{"EYWA_TEST_*"})
+	if len(table.Rows) != len(variables) {
+		t.Fatalf("got %d rows, want %d: %v", len(table.Rows), len(variables), table.Rows)
+	}
+	for _, row := range table.Rows {
+		name := row[0].(string)
+		if masked := row[1] != "s3cr3t-value"; masked != variables[name] {
+			t.Errorf("%s reported as %v, masked=%v, want masked=%v", name, row[1], masked, variables[name])
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// EYWA Error Logging for Go
+//
+// LogErr records the full structure of an error instead of its flattened
+// message: the unwrap chain (including errors joined with errors.Join),
+// the concrete type of every link, the fields of EYWA file errors and the
+// stack of the goroutine that logged it.
+
+package eywa
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// maxErrorDepth bounds chain traversal for self-referencing Unwrap implementations
+const maxErrorDepth = 32
+
+// maxStackFrames bounds the number of captured stack frames
+const maxStackFrames = 64
+
+// LogErr logs err at the given level together with its unwrapped chain,
+// concrete error types and the stack at the call site.
+//
+// Example:
+//
+//	if err := eywa.Upload(path, nil); err != nil {
+//	    eywa.LogErr(eywa.LOG_ERROR, "Upload failed", err)
+//	}
+func LogErr(level, message string, err error) {
+	if err == nil {
+		Log(level, message, nil, nil, nil, nil)
+		return
+	}
+	Log(level, message, errorDetails(err, 1), nil, nil, nil)
+}
+
+// errorDetails describes err as structured log data. skip is the number of
+// stack frames to omit above the caller of errorDetails.
+func errorDetails(err error, skip int) map[string]interface{} {
+	return map[string]interface{}{
+		"error": err.Error(),
+		"type":  fmt.Sprintf("%T", err),
+		"chain": errorChain(err, 0),
+		"stack": callerStack(skip + 1),
+	}
+}
+
+// errorChain walks err through errors.Unwrap. Joined errors (Unwrap() []error)
+// end the linear chain and list each branch under "joined".
+func errorChain(err error, depth int) []map[string]interface{} {
+	var chain []map[string]interface{}
+
+	for err != nil && depth < maxErrorDepth {
+		entry := describeError(err)
+
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			var branches [][]map[string]interface{}
+			for _, branch := range joined.Unwrap() {
+				if branch != nil {
+					branches = append(branches, errorChain(branch, depth+1))
+				}
+			}
+			entry["joined"] = branches
+			return append(chain, entry)
+		}
+
+		chain = append(chain, entry)
+		err = errors.Unwrap(err)
+		depth++
+	}
+
+	return chain
+}
+
+// describeError captures a single link of an error chain
+func describeError(err error) map[string]interface{} {
+	entry := map[string]interface{}{
+		"message": err.Error(),
+		"type":    fmt.Sprintf("%T", err),
+	}
+
+	switch e := err.(type) {
+	case *FileUploadError:
+		entry["kind"] = e.Type
+		if e.Code != nil {
+			entry["code"] = *e.Code
+		}
+	case *FileDownloadError:
+		entry["kind"] = e.Type
+		if e.Code != nil {
+			entry["code"] = *e.Code
+		}
+	}
+
+	return entry
+}
+
+// callerStack returns the current goroutine stack as "function (file:line)"
+// entries. skip is the number of frames to omit above the caller of callerStack.
+func callerStack(skip int) []string {
+	pcs := make([]uintptr, maxStackFrames)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []string
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			stack = append(stack, fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
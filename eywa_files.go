@@ -13,6 +13,7 @@ package eywa
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"fmt"
 	"io"
@@ -119,10 +120,7 @@ func Upload(filePath string, fileData map[string]interface{}) error {
 	contentType := getStringFromData(fileData, "content_type", detectMimeType(filePath))
 	euuid := getStringFromData(fileData, "euuid", generateUUID())
 	
-	var progressFn ProgressFn
-	if fn, ok := fileData["progressFn"].(ProgressFn); ok {
-		progressFn = fn
-	}
+	progressFn := getProgressFnFromData(fileData)
 
 	Info(fmt.Sprintf("Starting upload: %s (%d bytes)", name, size), nil)
 
@@ -244,10 +242,7 @@ func UploadStream(inputStream io.Reader, fileData map[string]interface{}) error
 	euuid := getStringFromData(fileData, "euuid", generateUUID())
 	contentType := getStringFromData(fileData, "content_type", "application/octet-stream")
 	
-	var progressFn ProgressFn
-	if fn, ok := fileData["progressFn"].(ProgressFn); ok {
-		progressFn = fn
-	}
+	progressFn := getProgressFnFromData(fileData)
 
 	Info(fmt.Sprintf("Starting stream upload: %s (%d bytes)", name, size), nil)
 
@@ -346,6 +341,11 @@ func UploadStream(inputStream io.Reader, fileData map[string]interface{}) error
 //
 // Returns: error (null on success)
 func UploadContent(content []byte, fileData map[string]interface{}) error {
+	return uploadContent(context.Background(), content, fileData, false)
+}
+
+// uploadContent uploads content within ctx, quiet skips the progress logs
+func uploadContent(ctx context.Context, content []byte, fileData map[string]interface{}, quiet bool) error {
 	if fileData == nil {
 		return NewFileUploadError("fileData is required")
 	}
@@ -359,12 +359,11 @@ func UploadContent(content []byte, fileData map[string]interface{}) error {
 	euuid := getStringFromData(fileData, "euuid", generateUUID())
 	contentType := getStringFromData(fileData, "content_type", "text/plain")
 	
-	var progressFn ProgressFn
-	if fn, ok := fileData["progressFn"].(ProgressFn); ok {
-		progressFn = fn
-	}
+	progressFn := getProgressFnFromData(fileData)
 
-	Info(fmt.Sprintf("Starting content upload: %s (%d bytes)", name, size), nil)
+	if !quiet {
+		WithContext(ctx).Info(fmt.Sprintf("Starting content upload: %s (%d bytes)", name, size), nil)
+	}
 
 	// Step 1: Request upload URL
 	uploadMutation := `
@@ -387,7 +386,7 @@ func UploadContent(content []byte, fileData map[string]interface{}) error {
 		variables["file"].(map[string]interface{})["folder"] = folder
 	}
 
-	result, err := GraphQL(uploadMutation, variables)
+	result, err := graphQLContext(ctx, uploadMutation, variables)
 	if err != nil {
 		return NewFileUploadError(fmt.Sprintf("Content upload failed: %s", err.Error()))
 	}
@@ -420,7 +419,7 @@ func UploadContent(content []byte, fileData map[string]interface{}) error {
 		}
 	`
 
-	confirmResult, err := GraphQL(confirmMutation, map[string]interface{}{
+	confirmResult, err := graphQLContext(ctx, confirmMutation, map[string]interface{}{
 		"url": uploadURL,
 	})
 	if err != nil {
@@ -432,7 +431,9 @@ func UploadContent(content []byte, fileData map[string]interface{}) error {
 		return NewFileUploadError("Upload confirmation failed")
 	}
 
-	Info(fmt.Sprintf("Content upload completed: %s -> %s", name, euuid), nil)
+	if !quiet {
+		WithContext(ctx).Info(fmt.Sprintf("Content upload completed: %s -> %s", name, euuid), nil)
+	}
 	return nil
 }
 
@@ -445,7 +446,15 @@ func UploadContent(content []byte, fileData map[string]interface{}) error {
 //   - *DownloadStreamResult - Stream with content length
 //   - error - Error if download fails
 func DownloadStream(fileUuid string) (*DownloadStreamResult, error) {
-	Info(fmt.Sprintf("Starting stream download: %s", fileUuid), nil)
+	return downloadStream(context.Background(), fileUuid, false)
+}
+
+// downloadStream opens a download within ctx, quiet skips the progress logs
+func downloadStream(ctx context.Context, fileUuid string, quiet bool) (*DownloadStreamResult, error) {
+	logger := WithContext(ctx)
+	if !quiet {
+		logger.Info(fmt.Sprintf("Starting stream download: %s", fileUuid), nil)
+	}
 
 	// Step 1: Request download URL
 	downloadQuery := `
@@ -454,13 +463,13 @@ func DownloadStream(fileUuid string) (*DownloadStreamResult, error) {
 		}
 	`
 
-	result, err := GraphQL(downloadQuery, map[string]interface{}{
+	result, err := graphQLContext(ctx, downloadQuery, map[string]interface{}{
 		"file": map[string]interface{}{
 			"euuid": fileUuid,
 		},
 	})
 	if err != nil {
-		Error("Download failed", map[string]interface{}{"error": err.Error()})
+		logger.Error("Download failed", map[string]interface{}{"error": err.Error()})
 		return nil, NewFileDownloadError(fmt.Sprintf("Download failed: %s", err.Error()))
 	}
 
@@ -469,7 +478,9 @@ func DownloadStream(fileUuid string) (*DownloadStreamResult, error) {
 		return nil, NewFileDownloadError("Failed to get download URL from response")
 	}
 
-	Debug(fmt.Sprintf("Download URL received: %s...", downloadURL[:minInt(50, len(downloadURL))]), nil)
+	if !quiet {
+		logger.Debug(fmt.Sprintf("Download URL received: %s...", downloadURL[:minInt(50, len(downloadURL))]), nil)
+	}
 
 	// Step 2: Create HTTP request for streaming
 	resp, err := http.Get(downloadURL)
@@ -495,7 +506,12 @@ func DownloadStream(fileUuid string) (*DownloadStreamResult, error) {
 //
 // Returns: []byte - Complete file content
 func Download(fileUuid string) ([]byte, error) {
-	stream, err := DownloadStream(fileUuid)
+	return download(context.Background(), fileUuid, false)
+}
+
+// download reads a file within ctx, quiet skips the progress logs
+func download(ctx context.Context, fileUuid string, quiet bool) ([]byte, error) {
+	stream, err := downloadStream(ctx, fileUuid, quiet)
 	if err != nil {
 		return nil, err
 	}
@@ -506,7 +522,9 @@ func Download(fileUuid string) ([]byte, error) {
 		return nil, NewFileDownloadError(fmt.Sprintf("Failed to read download content: %s", err.Error()))
 	}
 
-	Info(fmt.Sprintf("Download completed: %s (%d bytes)", fileUuid, len(content)), nil)
+	if !quiet {
+		WithContext(ctx).Info(fmt.Sprintf("Download completed: %s (%d bytes)", fileUuid, len(content)), nil)
+	}
 	return content, nil
 }
 
@@ -575,30 +593,36 @@ func CreateFolder(folderData map[string]interface{}) error {
 //
 // Returns: bool - true if deleted successfully
 func DeleteFile(fileUuid string) bool {
+	return deleteFile(context.Background(), fileUuid, false)
+}
+
+// deleteFile deletes a file within ctx, quiet skips the success log
+func deleteFile(ctx context.Context, fileUuid string, quiet bool) bool {
+	logger := WithContext(ctx)
 	mutation := `
 		mutation DeleteFile($uuid: UUID!) {
 			deleteFile(euuid: $uuid)
 		}
 	`
 
-	result, err := GraphQL(mutation, map[string]interface{}{
+	result, err := graphQLContext(ctx, mutation, map[string]interface{}{
 		"uuid": fileUuid,
 	})
 	if err != nil {
-		Error("Failed to delete file", map[string]interface{}{"error": err.Error()})
+		logger.Error("Failed to delete file", map[string]interface{}{"error": err.Error()})
 		return false
 	}
 
 	success, ok := result["data"].(map[string]interface{})["deleteFile"].(bool)
 	if !ok {
-		Warn("Unexpected response format for file deletion", nil)
+		logger.Warn("Unexpected response format for file deletion", nil)
 		return false
 	}
 
-	if success {
-		Info(fmt.Sprintf("File deleted: %s", fileUuid), nil)
-	} else {
-		Warn(fmt.Sprintf("File deletion failed: %s", fileUuid), nil)
+	if !success {
+		logger.Warn(fmt.Sprintf("File deletion failed: %s", fileUuid), nil)
+	} else if !quiet {
+		logger.Info(fmt.Sprintf("File deleted: %s", fileUuid), nil)
 	}
 
 	return success
@@ -652,6 +676,18 @@ func getStringFromData(data map[string]interface{}, key, defaultValue string) st
 	return defaultValue
 }
 
+// getProgressFnFromData accepts both ProgressFn values and plain
+// func(current, total int64) literals as progress callbacks
+func getProgressFnFromData(data map[string]interface{}) ProgressFn {
+	switch fn := data["progressFn"].(type) {
+	case ProgressFn:
+		return fn
+	case func(current, total int64):
+		return fn
+	}
+	return nil
+}
+
 func detectMimeType(filePath string) string {
 	contentType := mime.TypeByExtension(filepath.Ext(filePath))
 	if contentType == "" {
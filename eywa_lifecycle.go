@@ -0,0 +1,129 @@
+// EYWA Task Lifecycle for Go
+//
+// Task status is a typed Status and every change goes through a small state
+// machine, so typos and out-of-order calls are caught in the robot instead
+// of reaching EYWA:
+// - unknown statuses are rejected
+// - a task that failed (ERROR, EXCEPTION) cannot go back to PROCESSING
+// - nothing can be updated, logged or reported once the task is closed
+// - a task is closed only once, with SUCCESS, ERROR or EXCEPTION
+
+package eywa
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Status is the status of a task
+type Status string
+
+// Lifecycle errors
+var (
+	ErrInvalidStatus = errors.New("invalid task status")
+	ErrTaskClosed    = errors.New("task is already closed")
+)
+
+// TransitionError is returned for a status change the lifecycle does not allow
+type TransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("invalid task status transition from %s to %s", e.From, e.To)
+}
+
+// Valid reports whether s is one of the known statuses
+func (s Status) Valid() bool {
+	switch s {
+	case SUCCESS, ERROR, PROCESSING, EXCEPTION:
+		return true
+	}
+	return false
+}
+
+// Failed reports whether s marks the task as failed
+func (s Status) Failed() bool {
+	return s == ERROR || s == EXCEPTION
+}
+
+// CanClose reports whether a task can be closed with s
+func (s Status) CanClose() bool {
+	return s == SUCCESS || s.Failed()
+}
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// taskLifecycle tracks the status of one task
+type taskLifecycle struct {
+	mu      sync.Mutex
+	status  Status
+	closing bool
+	closed  bool
+}
+
+// lifecycle is the lifecycle of the task the robot runs
+var lifecycle = &taskLifecycle{}
+
+// update validates a status change from UpdateTask, an empty status only
+// checks that the task is still open
+func (l *taskLifecycle) update(status Status) error {
+	if status != "" && !status.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidStatus, string(status))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closing || l.closed {
+		return ErrTaskClosed
+	}
+	if l.status.Failed() && status == PROCESSING {
+		return &TransitionError{From: l.status, To: status}
+	}
+	if status != "" {
+		l.status = status
+	}
+	return nil
+}
+
+// beginClose reserves the close of the task. An invalid close status is
+// replaced by ERROR and reported in the returned error.
+func (l *taskLifecycle) beginClose(status Status) (Status, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closing || l.closed {
+		return status, ErrTaskClosed
+	}
+	l.closing = true
+
+	if !status.CanClose() {
+		err := fmt.Errorf("%w: cannot close task with %q, closing with ERROR", ErrInvalidStatus, string(status))
+		return ERROR, err
+	}
+	return status, nil
+}
+
+// finishClose marks the task as closed
+func (l *taskLifecycle) finishClose(status Status) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.status = status
+	l.closed = true
+}
+
+// isClosed reports whether the task was closed
+func (l *taskLifecycle) isClosed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
+
+// lifecycleWarning writes a lifecycle violation to stderr
+func lifecycleWarning(format string, args ...interface{}) {
+	log.Printf("eywa: "+format, args...)
+}
@@ -0,0 +1,155 @@
+package eywa
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTaskLifecycle(t *testing.T) {
+	type step struct {
+		op     string // "update", "begin" (beginClose only) or "close"
+		status Status
+		want   string // "", "invalid", "transition" or "closed"
+		closed Status // Status beginClose settles on
+	}
+	tests := []struct {
+		name   string
+		steps  []step
+		status Status // Final status
+		closed bool
+	}{
+		{"processing then success", []step{
+			{op: "update", status: PROCESSING},
+			{op: "close", status: SUCCESS, closed: SUCCESS},
+		}, SUCCESS, true},
+		{"unknown status", []step{
+			{op: "update", status: PROCESSING},
+			{op: "update", status: "DONE", want: "invalid"},
+		}, PROCESSING, false},
+		{"processing after error", []step{
+			{op: "update", status: ERROR},
+			{op: "update", status: PROCESSING, want: "transition"},
+		}, ERROR, false},
+		{"processing after exception", []step{
+			{op: "update", status: EXCEPTION},
+			{op: "update", status: PROCESSING, want: "transition"},
+		}, EXCEPTION, false},
+		{"empty status after error", []step{
+			{op: "update", status: ERROR},
+			{op: "update"},
+		}, ERROR, false},
+		{"processing after success", []step{
+			{op: "update", status: SUCCESS},
+			{op: "update", status: PROCESSING},
+		}, PROCESSING, false},
+		{"close twice", []step{
+			{op: "close", status: ERROR, closed: ERROR},
+			{op: "close", status: SUCCESS, want: "closed", closed: SUCCESS},
+		}, ERROR, true},
+		{"close with processing", []step{
+			{op: "close", status: PROCESSING, want: "invalid", closed: ERROR},
+		}, ERROR, true},
+		{"close with unknown status", []step{
+			{op: "close", status: "DONE", want: "invalid", closed: ERROR},
+		}, ERROR, true},
+		{"update after close", []step{
+			{op: "close", status: SUCCESS, closed: SUCCESS},
+			{op: "update", status: PROCESSING, want: "closed"},
+			{op: "update", want: "closed"},
+		}, SUCCESS, true},
+		{"update while closing", []step{
+			{op: "update", status: PROCESSING},
+			{op: "begin", status: SUCCESS, closed: SUCCESS},
+			{op: "update", want: "closed"},
+			{op: "begin", status: ERROR, want: "closed", closed: ERROR},
+		}, PROCESSING, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &taskLifecycle{}
+			for i, s := range tt.steps {
+				var err error
+				switch s.op {
+				case "update":
+					err = l.update(s.status)
+				case "begin", "close":
+					var status Status
+					status, err = l.beginClose(s.status)
+					if status != s.closed {
+						t.Errorf("step %d: beginClose(%q) settled on %q, want %q", i, s.status, status, s.closed)
+					}
+					if s.op == "close" && !errors.Is(err, ErrTaskClosed) {
+						l.finishClose(status)
+					}
+				}
+
+				var transition *TransitionError
+				got := ""
+				switch {
+				case err == nil:
+				case errors.Is(err, ErrInvalidStatus):
+					got = "invalid"
+				case errors.Is(err, ErrTaskClosed):
+					got = "closed"
+				case errors.As(err, &transition):
+					got = "transition"
+				default:
+					got = err.Error()
+				}
+				if got != s.want {
+					t.Errorf("step %d: %s(%q) = %v, want %q", i, s.op, s.status, err, s.want)
+				}
+			}
+
+			if l.status != tt.status || l.isClosed() != tt.closed {
+				t.Errorf("ended as %q closed=%v, want %q closed=%v", l.status, l.isClosed(), tt.status, tt.closed)
+			}
+		})
+	}
+}
+
+func TestClosedTaskDropsLogsAndReports(t *testing.T) {
+	var output bytes.Buffer
+	EnableDevMode(&DevOptions{Output: &output, NoColor: true})
+
+	taskMu.Lock()
+	previousTask := currentTask
+	currentTask = &Task{EUUID: "77777777-7777-4777-8777-777777777777"}
+	taskMu.Unlock()
+	previousLifecycle := lifecycle
+	lifecycle = &taskLifecycle{}
+	defer func() {
+		taskMu.Lock()
+		currentTask = previousTask
+		taskMu.Unlock()
+		lifecycle = previousLifecycle
+	}()
+
+	ctx := context.Background()
+	emitLog(ctx, LogParams{Event: INFO, Message: "before close"})
+	if err := reportContext(ctx, "Before close", nil); err != nil {
+		t.Fatalf("report before close: %v", err)
+	}
+	if output.Len() == 0 {
+		t.Fatal("nothing logged before close")
+	}
+
+	if _, err := closeTaskContext(ctx, TaskParams{Status: SUCCESS}, nil); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	output.Reset()
+
+	emitLog(ctx, LogParams{Event: INFO, Message: "after close"})
+	if err := reportContext(ctx, "After close", nil); !errors.Is(err, ErrTaskClosed) {
+		t.Errorf("report after close = %v, want ErrTaskClosed", err)
+	}
+	if err := updateTaskContext(ctx, PROCESSING, nil); !errors.Is(err, ErrTaskClosed) {
+		t.Errorf("update after close = %v, want ErrTaskClosed", err)
+	}
+	if output.Len() > 0 {
+		t.Errorf("closed task still sent:\n%s", output.String())
+	}
+}
@@ -0,0 +1,172 @@
+// EYWA Context Logger for Go
+//
+// Logger carries a context.Context into logging, reporting and GraphQL so
+// that trace ids travel with every call made through it. The package-level
+// functions (Info, Report, GraphQL, ...) behave like a Logger for
+// context.Background().
+
+package eywa
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// Logger logs, reports and queries EYWA within a context. A Logger with
+// coordinates attaches them to every entry it logs.
+type Logger struct {
+	ctx         context.Context
+	coordinates *Coordinates
+}
+
+// WithContext returns a Logger bound to ctx
+//
+// Example:
+//
+//	ctx, _ := eywa.StartSpan(ctx)
+//	logger := eywa.WithContext(ctx)
+//	logger.Info("Fetching invoices", nil)
+//	result, err := logger.GraphQL(query, nil)
+func WithContext(ctx context.Context) *Logger {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Logger{ctx: ctx}
+}
+
+// Context returns the context the Logger is bound to
+func (l *Logger) Context() context.Context {
+	return l.ctx
+}
+
+// WithContext returns a copy of the Logger bound to ctx
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	clone := *l
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	clone.ctx = ctx
+	return &clone
+}
+
+// WithCoordinates returns a Logger that attaches coordinates to every entry
+//
+// Example:
+//
+//	logger := eywa.WithCoordinates(eywa.NewCoordinates("import", "parse"))
+//	for _, invoice := range invoices {
+//	    logger.WithItem(invoice.Number).Info("Invoice parsed", nil)
+//	}
+func WithCoordinates(coordinates *Coordinates) *Logger {
+	return WithContext(context.Background()).WithCoordinates(coordinates)
+}
+
+// WithCoordinates returns a copy of the Logger with coordinates replaced
+func (l *Logger) WithCoordinates(coordinates *Coordinates) *Logger {
+	clone := *l
+	clone.coordinates = coordinates
+	return &clone
+}
+
+// Coordinates returns the coordinates attached by the Logger
+func (l *Logger) Coordinates() *Coordinates {
+	return l.coordinates
+}
+
+// WithStage returns a copy of the Logger with the coordinate stage set
+func (l *Logger) WithStage(stage string) *Logger {
+	return l.WithCoordinates(l.coordinates.WithStage(stage))
+}
+
+// WithStep returns a copy of the Logger with the coordinate step set
+func (l *Logger) WithStep(step string) *Logger {
+	return l.WithCoordinates(l.coordinates.WithStep(step))
+}
+
+// WithItem returns a copy of the Logger with the coordinate item set
+func (l *Logger) WithItem(item string) *Logger {
+	return l.WithCoordinates(l.coordinates.WithItem(item))
+}
+
+// Log sends a log message with full control over parameters. Typed
+// coordinates passed here are merged over those of the Logger.
+func (l *Logger) Log(event, message string, data interface{}, duration *int, coordinates interface{}, logTime *time.Time) {
+	if l.coordinates != nil {
+		switch c := coordinates.(type) {
+		case nil:
+			coordinates = l.coordinates
+		case *Coordinates:
+			coordinates = l.coordinates.merge(c)
+		case Coordinates:
+			coordinates = l.coordinates.merge(&c)
+		}
+	}
+	logContext(l.ctx, event, message, data, duration, coordinates, logTime)
+}
+
+// Info logs an info message
+func (l *Logger) Info(message string, data interface{}) {
+	l.Log(INFO, message, data, nil, nil, nil)
+}
+
+// Error logs an error message
+func (l *Logger) Error(message string, data interface{}) {
+	l.Log(LOG_ERROR, message, data, nil, nil, nil)
+}
+
+// Warn logs a warning message
+func (l *Logger) Warn(message string, data interface{}) {
+	l.Log(WARN, message, data, nil, nil, nil)
+}
+
+// Debug logs a debug message
+func (l *Logger) Debug(message string, data interface{}) {
+	l.Log(DEBUG, message, data, nil, nil, nil)
+}
+
+// Trace logs a trace message
+func (l *Logger) Trace(message string, data interface{}) {
+	l.Log(TRACE, message, data, nil, nil, nil)
+}
+
+// Exception logs an exception message
+func (l *Logger) Exception(message string, data interface{}) {
+	l.Log(LOG_EXCEPTION, message, data, nil, nil, nil)
+}
+
+// LogErr logs err with its chain and stack, see LogErr
+func (l *Logger) LogErr(level, message string, err error) {
+	if err == nil {
+		l.Log(level, message, nil, nil, nil, nil)
+		return
+	}
+	l.Log(level, message, errorDetails(err, 1), nil, nil, nil)
+}
+
+// Report creates a structured task report, see Report
+func (l *Logger) Report(message string, options *ReportOptions) error {
+	return reportContext(l.ctx, message, options)
+}
+
+// GraphQL executes a GraphQL query, see GraphQL
+func (l *Logger) GraphQL(query string, variables map[string]interface{}) (map[string]interface{}, error) {
+	return graphQLContext(l.ctx, query, variables)
+}
+
+// NewProgress creates a progress tracker that logs and updates the task of
+// the Logger, see NewProgress
+func (l *Logger) NewProgress(message string, total int64, options *ProgressOptions) *Progress {
+	return newProgress(l, message, total, options)
+}
+
+// LogWriter returns a writer that logs every line through the Logger, see LogWriter
+func (l *Logger) LogWriter(level string) io.WriteCloser {
+	return newLineWriter(l, level, nil)
+}
+
+// RunCommand runs cmd and logs its output through the Logger, see RunCommand
+func (l *Logger) RunCommand(cmd *exec.Cmd) error {
+	return runCommand(l, cmd)
+}
@@ -0,0 +1,189 @@
+// EYWA Log Writer for Go
+//
+// Adapts io.Writer based output (external commands, third party loggers)
+// to EYWA task logs. Input is split into lines and every line becomes a
+// separate log entry. The Logger variants log for the task of the Logger,
+// e.g. a TaskSession in service mode.
+
+package eywa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLogLineLength is the longest line emitted as a single log entry.
+// Longer lines are split into several entries.
+const maxLogLineLength = 16 * 1024
+
+// lineWriter splits written bytes into lines and logs each of them
+type lineWriter struct {
+	mu        sync.Mutex
+	logger    *Logger
+	level     string
+	data      map[string]interface{}
+	buf       []byte
+	pendingCR bool
+	closed    bool
+}
+
+// LogWriter returns an io.WriteCloser that logs every written line at the
+// given level. Partial lines are buffered until a newline arrives or the
+// writer is closed. A carriage return that is not followed by a newline
+// rewrites the current line (progress bars), so only the final state is logged.
+//
+// Example:
+//
+//	w := eywa.LogWriter(eywa.INFO)
+//	defer w.Close()
+//	log.SetOutput(w)
+func LogWriter(level string) io.WriteCloser {
+	return newLineWriter(WithContext(context.Background()), level, nil)
+}
+
+func newLineWriter(logger *Logger, level string, data map[string]interface{}) *lineWriter {
+	return &lineWriter{
+		logger: logger,
+		level:  level,
+		data:   data,
+	}
+}
+
+// Write implements io.Writer
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errors.New("eywa: write to closed log writer")
+	}
+
+	for _, b := range p {
+		if w.pendingCR {
+			w.pendingCR = false
+			if b != '\n' {
+				// Bare carriage return, the line is being rewritten
+				w.buf = w.buf[:0]
+			}
+		}
+
+		switch b {
+		case '\n':
+			w.flush()
+		case '\r':
+			w.pendingCR = true
+		default:
+			w.buf = append(w.buf, b)
+			if len(w.buf) >= maxLogLineLength {
+				w.flushLong()
+			}
+		}
+	}
+
+	return len(p), nil
+}
+
+// Close logs any buffered partial line. Further writes fail.
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	w.flush()
+	return nil
+}
+
+// flushLong logs a line that reached maxLogLineLength. A multi-byte
+// character that is not complete yet is kept for the next entry instead of
+// being split. Callers must hold w.mu.
+func (w *lineWriter) flushLong() {
+	cut := len(w.buf)
+	start := cut - 1
+	for start > 0 && cut-start < utf8.UTFMax && !utf8.RuneStart(w.buf[start]) {
+		start--
+	}
+	if !utf8.FullRune(w.buf[start:]) {
+		cut = start
+	}
+
+	rest := append([]byte(nil), w.buf[cut:]...)
+	w.buf = w.buf[:cut]
+	w.flush()
+	w.buf = append(w.buf, rest...)
+}
+
+// flush logs the buffered line, callers must hold w.mu
+func (w *lineWriter) flush() {
+	line := strings.TrimRight(string(w.buf), " \t")
+	w.buf = w.buf[:0]
+	if line == "" {
+		return
+	}
+
+	var data interface{}
+	if w.data != nil {
+		data = w.data
+	}
+	w.logger.Log(w.level, line, data, nil, nil, nil)
+}
+
+// RunCommand runs cmd and streams its output into the task log: stdout
+// lines are logged as INFO and stderr lines as WARN. The exit status and
+// duration are logged once the command finishes. Any Stdout or Stderr
+// already set on cmd is replaced.
+//
+// Example:
+//
+//	err := eywa.RunCommand(exec.Command("pg_dump", "-f", "backup.sql", "mydb"))
+func RunCommand(cmd *exec.Cmd) error {
+	return runCommand(WithContext(context.Background()), cmd)
+}
+
+// runCommand runs cmd and logs its output through logger
+func runCommand(logger *Logger, cmd *exec.Cmd) error {
+	command := strings.Join(cmd.Args, " ")
+	if command == "" {
+		command = cmd.Path
+	}
+
+	stdout := newLineWriter(logger, INFO, map[string]interface{}{"stream": "stdout"})
+	stderr := newLineWriter(logger, WARN, map[string]interface{}{"stream": "stderr"})
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	logger.Info(fmt.Sprintf("Running command: %s", command), nil)
+
+	start := time.Now()
+	err := cmd.Run()
+	duration := int(time.Since(start).Milliseconds())
+
+	stdout.Close()
+	stderr.Close()
+
+	result := map[string]interface{}{
+		"command":   command,
+		"exit_code": -1,
+	}
+	if cmd.ProcessState != nil {
+		result["exit_code"] = cmd.ProcessState.ExitCode()
+	}
+
+	if err != nil {
+		result["error"] = err.Error()
+		logger.Log(LOG_ERROR, fmt.Sprintf("Command failed: %s", command), result, &duration, nil, nil)
+		return err
+	}
+
+	logger.Log(INFO, fmt.Sprintf("Command finished: %s", command), result, &duration, nil, nil)
+	return nil
+}
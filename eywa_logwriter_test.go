@@ -0,0 +1,94 @@
+package eywa
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// logLines captures a lineWriter's entries as the dev console prints them
+func logLines(t *testing.T, write func(w *lineWriter)) []string {
+	t.Helper()
+	var output bytes.Buffer
+	EnableDevMode(&DevOptions{Output: &output, NoColor: true})
+
+	w := newLineWriter(WithContext(context.Background()), INFO, nil)
+	write(w)
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		// "15:04:05.000 INFO      message"
+		messages = append(messages, line[len("15:04:05.000 INFO      "):])
+	}
+	return messages
+}
+
+func TestLineWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		close  bool
+		want   []string
+	}{
+		{"lines", []string{"first\nsecond\n"}, false, []string{"first", "second"}},
+		{"bare carriage return rewrites", []string{"10%\r50%\r100%\n"}, false, []string{"100%"}},
+		{"CRLF split across writes", []string{"line one\r", "\nline two\r\n"}, false, []string{"line one", "line two"}},
+		{"partial line kept until close", []string{"no newline"}, false, nil},
+		{"partial line flushed on close", []string{"no ", "newline"}, true, []string{"no newline"}},
+		{"blank lines skipped", []string{"\n  \n\tx \n"}, false, []string{"\tx"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := logLines(t, func(w *lineWriter) {
+				for _, chunk := range tt.writes {
+					if n, err := w.Write([]byte(chunk)); err != nil || n != len(chunk) {
+						t.Fatalf("Write = %d, %v", n, err)
+					}
+				}
+				if tt.close {
+					w.Close()
+				}
+			})
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("logged %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineWriterWriteAfterClose(t *testing.T) {
+	logLines(t, func(w *lineWriter) {
+		w.Close()
+		if _, err := w.Write([]byte("late\n")); err == nil {
+			t.Error("write after close succeeded")
+		}
+	})
+}
+
+func TestLineWriterKeepsRunesWholeAtLimit(t *testing.T) {
+	const emoji = "\U0001F600" // 4 bytes
+	for offset := 1; offset < utf8.UTFMax; offset++ {
+		prefix := strings.Repeat("a", maxLogLineLength-offset)
+		got := logLines(t, func(w *lineWriter) {
+			// Byte by byte, the way a pipe may deliver it
+			for _, b := range []byte(prefix + emoji + "\n") {
+				w.Write([]byte{b})
+			}
+		})
+
+		if len(got) != 2 || got[0] != prefix || got[1] != emoji {
+			t.Errorf("offset %d: got %d entries, want the prefix and the whole rune", offset, len(got))
+			continue
+		}
+		for _, message := range got {
+			if !utf8.ValidString(message) {
+				t.Errorf("offset %d: entry split a rune", offset)
+			}
+		}
+	}
+}
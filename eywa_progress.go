@@ -0,0 +1,287 @@
+// EYWA Progress Reporting for Go
+//
+// Progress tracks work on large batches and reports it as throttled log
+// entries ("Importing: 3,400 / 10,000 records (34%)") with rate and ETA,
+// instead of one log entry per processed item. Logger.NewProgress reports
+// for the task of the Logger, e.g. a TaskSession in service mode.
+
+package eywa
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress reporting defaults
+const (
+	DefaultProgressInterval    = 5 * time.Second
+	DefaultProgressPercentStep = 10.0
+)
+
+// ProgressOptions configures a progress tracker
+type ProgressOptions struct {
+	Unit        string        `json:"unit,omitempty"`         // Item label, e.g. "records"
+	Level       string        `json:"level,omitempty"`        // Log level, defaults to INFO
+	Interval    time.Duration `json:"interval,omitempty"`     // Longest silence between updates
+	PercentStep float64       `json:"percent_step,omitempty"` // Percentage change that forces an update
+	UpdateTask  bool          `json:"update_task,omitempty"`  // Also show message and percentage on the task
+}
+
+// Progress tracks the completion of a batch of work. It is safe for
+// concurrent use.
+type Progress struct {
+	mu          sync.Mutex
+	logger      *Logger
+	message     string
+	options     ProgressOptions
+	current     int64
+	total       int64
+	started     time.Time
+	lastEmit    time.Time
+	lastPercent float64
+	done        bool
+}
+
+// ProgressSnapshot is the state of a progress tracker at one point in time
+type ProgressSnapshot struct {
+	Current int64         `json:"current"`
+	Total   int64         `json:"total,omitempty"`
+	Percent float64       `json:"percent,omitempty"`
+	Rate    float64       `json:"rate"`
+	Elapsed time.Duration `json:"-"`
+	ETA     time.Duration `json:"-"`
+}
+
+// NewProgress creates a progress tracker. A total of 0 means the amount of
+// work is unknown, in which case only counts and rates are reported.
+//
+// Example:
+//
+//	progress := eywa.NewProgress("Importing invoices", int64(len(rows)), &eywa.ProgressOptions{
+//	    Unit: "records",
+//	})
+//	for _, row := range rows {
+//	    importRow(row)
+//	    progress.Add(1)
+//	}
+//	progress.Done()
+func NewProgress(message string, total int64, options *ProgressOptions) *Progress {
+	return newProgress(WithContext(context.Background()), message, total, options)
+}
+
+// newProgress creates a progress tracker that logs through logger
+func newProgress(logger *Logger, message string, total int64, options *ProgressOptions) *Progress {
+	opts := ProgressOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Level == "" {
+		opts.Level = INFO
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultProgressInterval
+	}
+	if opts.PercentStep <= 0 {
+		opts.PercentStep = DefaultProgressPercentStep
+	}
+
+	now := time.Now()
+	return &Progress{
+		logger:   logger,
+		message:  message,
+		options:  opts,
+		total:    total,
+		started:  now,
+		lastEmit: now,
+	}
+}
+
+// Add advances progress by n items
+func (p *Progress) Add(n int64) {
+	p.mu.Lock()
+	p.current += n
+	params := p.update(false)
+	p.mu.Unlock()
+
+	p.emit(params)
+}
+
+// Set sets the absolute progress. A total greater than 0 replaces the
+// previously known total.
+func (p *Progress) Set(current, total int64) {
+	p.mu.Lock()
+	p.current = current
+	if total > 0 {
+		p.total = total
+	}
+	params := p.update(false)
+	p.mu.Unlock()
+
+	p.emit(params)
+}
+
+// Done reports the final state regardless of throttling. Further updates
+// are ignored.
+func (p *Progress) Done() {
+	p.mu.Lock()
+	params := p.update(true)
+	p.done = true
+	p.mu.Unlock()
+
+	p.emit(params)
+}
+
+// Snapshot returns the current progress with rate and ETA
+func (p *Progress) Snapshot() ProgressSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.snapshot(time.Now())
+}
+
+// TransferFn returns a ProgressFn that drives this tracker from file
+// transfers, e.g. as the "progressFn" of Upload or UploadContent
+func (p *Progress) TransferFn() ProgressFn {
+	return func(current, total int64) {
+		p.Set(current, total)
+	}
+}
+
+// update decides whether the new state is reported, callers must hold p.mu
+func (p *Progress) update(force bool) *LogParams {
+	if p.done {
+		return nil
+	}
+
+	now := time.Now()
+	snapshot := p.snapshot(now)
+	complete := p.total > 0 && p.current >= p.total
+
+	if !force && !complete &&
+		now.Sub(p.lastEmit) < p.options.Interval &&
+		(p.total <= 0 || snapshot.Percent-p.lastPercent < p.options.PercentStep) {
+		return nil
+	}
+
+	p.lastEmit = now
+	p.lastPercent = snapshot.Percent
+	if complete {
+		p.done = true
+	}
+
+	data := map[string]interface{}{
+		"current":         snapshot.Current,
+		"rate":            round2(snapshot.Rate),
+		"elapsed_seconds": round2(snapshot.Elapsed.Seconds()),
+	}
+	if p.total > 0 {
+		data["total"] = snapshot.Total
+		data["percent"] = round2(snapshot.Percent)
+		if !complete && snapshot.Rate > 0 {
+			data["eta_seconds"] = round2(snapshot.ETA.Seconds())
+		}
+	}
+	if p.options.Unit != "" {
+		data["unit"] = p.options.Unit
+	}
+
+	return &LogParams{
+		Event:   p.options.Level,
+		Message: p.format(snapshot, complete),
+		Data:    data,
+	}
+}
+
+func (p *Progress) snapshot(now time.Time) ProgressSnapshot {
+	snapshot := ProgressSnapshot{
+		Current: p.current,
+		Total:   p.total,
+		Elapsed: now.Sub(p.started),
+	}
+	if seconds := snapshot.Elapsed.Seconds(); seconds > 0 {
+		snapshot.Rate = float64(p.current) / seconds
+	}
+	if p.total > 0 {
+		snapshot.Percent = math.Min(100, float64(p.current)*100/float64(p.total))
+		if remaining := p.total - p.current; remaining > 0 && snapshot.Rate > 0 {
+			snapshot.ETA = time.Duration(float64(remaining) / snapshot.Rate * float64(time.Second))
+		}
+	}
+	return snapshot
+}
+
+// format renders "Message: 3,400 / 10,000 records (34%), 120.5 records/s, ETA 56s"
+func (p *Progress) format(snapshot ProgressSnapshot, complete bool) string {
+	unit := ""
+	if p.options.Unit != "" {
+		unit = " " + p.options.Unit
+	}
+
+	var text strings.Builder
+	text.WriteString(p.message)
+	text.WriteString(": ")
+	if p.total > 0 {
+		text.WriteString(fmt.Sprintf("%s / %s%s (%.0f%%)", formatCount(p.current), formatCount(p.total), unit, math.Floor(snapshot.Percent)))
+	} else {
+		text.WriteString(formatCount(p.current) + unit)
+	}
+
+	perSecond := "/s"
+	if unit != "" {
+		perSecond = unit + "/s"
+	}
+	text.WriteString(fmt.Sprintf(", %.1f%s", snapshot.Rate, perSecond))
+
+	if complete {
+		text.WriteString(fmt.Sprintf(", done in %s", snapshot.Elapsed.Round(time.Second)))
+	} else if snapshot.ETA >= time.Second {
+		text.WriteString(fmt.Sprintf(", ETA %s", snapshot.ETA.Round(time.Second)))
+	} else if snapshot.ETA > 0 {
+		text.WriteString(", ETA <1s")
+	}
+	return text.String()
+}
+
+func (p *Progress) emit(params *LogParams) {
+	if params == nil {
+		return
+	}
+	p.logger.Log(params.Event, params.Message, params.Data, nil, nil, nil)
+
+	if !p.options.UpdateTask {
+		return
+	}
+	options := &TaskUpdateOptions{Message: p.message}
+	if data, ok := params.Data.(map[string]interface{}); ok {
+		if percent, ok := data["percent"].(float64); ok {
+			options.Progress = &percent
+		}
+	}
+	updateTaskContext(p.logger.Context(), "", options)
+}
+
+// formatCount renders an integer with thousands separators (10000 -> "10,000")
+func formatCount(n int64) string {
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+
+	digits := fmt.Sprintf("%d", n)
+	var out strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out.WriteByte(',')
+		}
+		out.WriteRune(digit)
+	}
+	return sign + out.String()
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,341 @@
+// EYWA Secret Redaction for Go
+//
+// Every log entry and report passes through a redaction layer before it is
+// sent to EYWA. Values are masked when:
+// - a segment of their map key matches a sensitive key pattern (password,
+//   token, ...); keys are split at _ - . spaces and camelCase boundaries
+// - they match one of the registered value regular expressions
+// - they contain a secret registered with RegisterSecret
+
+package eywa
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DefaultRedactionMask is the replacement used for redacted values
+const DefaultRedactionMask = "[REDACTED]"
+
+// MaskFunc produces the replacement text for a redacted value
+type MaskFunc func(value string) string
+
+// Redaction configuration, guarded by redactMu
+var (
+	redactMu       sync.RWMutex
+	redactEnabled  = true
+	redactKeys     = [][]string{{"password"}, {"token"}, {"authorization"}, {"secret"}} // Key patterns split into segments
+	redactPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-._~+/]+=*`),
+		regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	}
+	redactSecrets []string
+	redactMask    = FixedMask(DefaultRedactionMask)
+)
+
+// minSecretLength protects logs from being shredded by trivially short secrets
+const minSecretLength = 4
+
+// FixedMask replaces every redacted value with the same text
+func FixedMask(mask string) MaskFunc {
+	return func(string) string {
+		return mask
+	}
+}
+
+// PartialMask keeps the last visible characters of a value and prefixes
+// them with mask, e.g. PartialMask(4, "****") turns "sk-12345678" into "****5678".
+// Values that are not longer than visible are fully masked.
+func PartialMask(visible int, mask string) MaskFunc {
+	return func(value string) string {
+		runes := []rune(value)
+		if visible <= 0 || len(runes) <= visible {
+			return mask
+		}
+		return mask + string(runes[len(runes)-visible:])
+	}
+}
+
+// SetRedaction enables or disables redaction of logs and reports
+func SetRedaction(enabled bool) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	redactEnabled = enabled
+}
+
+// SetRedactionMask sets the function that produces replacement text
+func SetRedactionMask(mask MaskFunc) {
+	if mask == nil {
+		mask = FixedMask(DefaultRedactionMask)
+	}
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	redactMask = mask
+}
+
+// RedactKeys adds key patterns to the sensitive key list. Keys and patterns
+// are split into segments at _ - . spaces and camelCase boundaries and
+// compared case-insensitively: "api_key" matches "X-Api-Key" and "apiKey",
+// but not "apikeys".
+func RedactKeys(patterns ...string) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	for _, pattern := range patterns {
+		if segments := keySegments(pattern); len(segments) > 0 {
+			redactKeys = append(redactKeys, segments)
+		}
+	}
+}
+
+// RedactValues adds regular expressions whose matches are masked in every
+// string value, log message and report card
+func RedactValues(patterns ...*regexp.Regexp) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	for _, pattern := range patterns {
+		if pattern != nil {
+			redactPatterns = append(redactPatterns, pattern)
+		}
+	}
+}
+
+// RegisterSecret registers known secret strings (API keys, passwords read
+// from configuration, ...) that must never appear in logs or reports.
+// Secrets shorter than 4 characters are ignored.
+func RegisterSecret(secrets ...string) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			redactSecrets = append(redactSecrets, secret)
+		}
+	}
+}
+
+// isSensitiveKey reports whether a map key or table header matches a key pattern
+func isSensitiveKey(key string) bool {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	if !redactEnabled {
+		return false
+	}
+	segments := keySegments(key)
+	for _, pattern := range redactKeys {
+		if containsSegments(segments, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// keySegments splits key into lower case words at _ - . and spaces and at
+// camelCase boundaries, e.g. "X-Auth-Token" and "xAuthToken" both become
+// [x auth token] and "HTTPHeader" becomes [http header]
+func keySegments(key string) []string {
+	var segments []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			segments = append(segments, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == '.' || unicode.IsSpace(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0:
+			previous := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return segments
+}
+
+// containsSegments reports whether pattern occurs as a run of whole
+// segments in segments
+func containsSegments(segments, pattern []string) bool {
+	for start := 0; start+len(pattern) <= len(segments); start++ {
+		matched := true
+		for i, word := range pattern {
+			if segments[start+i] != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// redactString masks registered secrets and value pattern matches in s
+func redactString(s string) string {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	if !redactEnabled || s == "" {
+		return s
+	}
+	for _, secret := range redactSecrets {
+		if strings.Contains(s, secret) {
+			s = strings.ReplaceAll(s, secret, redactMask(secret))
+		}
+	}
+	for _, pattern := range redactPatterns {
+		s = pattern.ReplaceAllStringFunc(s, redactMask)
+	}
+	return s
+}
+
+// maskValue replaces a value stored under a sensitive key. Strings nested in
+// lists and maps are masked individually, other values are masked whole.
+func maskValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case string:
+		redactMu.RLock()
+		defer redactMu.RUnlock()
+		return redactMask(x)
+	case []interface{}:
+		masked := make([]interface{}, len(x))
+		for i, value := range x {
+			masked[i] = maskValue(value)
+		}
+		return masked
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(x))
+		for key, value := range x {
+			masked[key] = maskValue(value)
+		}
+		return masked
+	case nil, bool, float64, float32, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, json.Number:
+	default:
+		if generic, ok := toGeneric(v); ok {
+			return maskValue(generic)
+		}
+	}
+
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	return redactMask("")
+}
+
+// redactValue returns a redacted copy of arbitrary log data. Values that are
+// not plain JSON types are converted through encoding/json first so that
+// structs and typed maps (e.g. http.Header) are inspected as well.
+func redactValue(v interface{}) interface{} {
+	return redactTree(v, true)
+}
+
+// redactStrings returns a copy of v with secrets and value pattern matches
+// masked in its strings. Keys are not inspected, so numbers and flags of
+// machine-readable data such as task results keep their types.
+func redactStrings(v interface{}) interface{} {
+	return redactTree(v, false)
+}
+
+// redactTree redacts the strings in v and, with maskKeys, masks the values
+// stored under sensitive keys
+func redactTree(v interface{}, maskKeys bool) interface{} {
+	switch x := v.(type) {
+	case nil, bool, float64, float32, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, json.Number:
+		return v
+	case string:
+		return redactString(x)
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(x))
+		for key, value := range x {
+			if maskKeys && isSensitiveKey(key) {
+				redacted[key] = maskValue(value)
+			} else {
+				redacted[key] = redactTree(value, maskKeys)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(x))
+		for i, value := range x {
+			redacted[i] = redactTree(value, maskKeys)
+		}
+		return redacted
+	}
+
+	if generic, ok := toGeneric(v); ok {
+		return redactTree(generic, maskKeys)
+	}
+	return v
+}
+
+// toGeneric converts v into plain JSON types (maps, slices, strings, ...)
+func toGeneric(v interface{}) (interface{}, bool) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	var generic interface{}
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return nil, false
+	}
+	return generic, true
+}
+
+// redactReportData returns a redacted copy of report card and tables.
+// Whole table columns are masked when their header matches a key pattern.
+func redactReportData(data *ReportData) *ReportData {
+	if data == nil {
+		return nil
+	}
+
+	redacted := &ReportData{
+		Card: redactString(data.Card),
+	}
+
+	if data.Tables != nil {
+		redacted.Tables = make(map[string]TableData, len(data.Tables))
+		for name, table := range data.Tables {
+			sensitive := make([]bool, len(table.Headers))
+			for i, header := range table.Headers {
+				sensitive[i] = isSensitiveKey(header)
+			}
+
+			var rows [][]interface{}
+			if table.Rows != nil {
+				rows = make([][]interface{}, len(table.Rows))
+			}
+			for i, row := range table.Rows {
+				if row == nil {
+					continue
+				}
+				cells := make([]interface{}, len(row))
+				for j, cell := range row {
+					if j < len(sensitive) && sensitive[j] {
+						cells[j] = maskValue(sanitizeValue(cell))
+					} else {
+						cells[j] = redactedValue(cell)
+					}
+				}
+				rows[i] = cells
+			}
+
+			redacted.Tables[name] = TableData{
+				Headers: table.Headers,
+				Rows:    rows,
+			}
+		}
+	}
+
+	return redacted
+}
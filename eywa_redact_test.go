@@ -0,0 +1,91 @@
+package eywa
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestKeySegments(t *testing.T) {
+	tests := map[string][]string{
+		"api_token":        {"api", "token"},
+		"X-Auth-Token":     {"x", "auth", "token"},
+		"accessToken":      {"access", "token"},
+		"HTTPHeader":       {"http", "header"},
+		"client.secret 2":  {"client", "secret", "2"},
+		"oauth2Token":      {"oauth2", "token"},
+		"PASSWORD":         {"password"},
+		"__tokens__used__": {"tokens", "used"},
+	}
+	for key, want := range tests {
+		if got := keySegments(key); !reflect.DeepEqual(got, want) {
+			t.Errorf("keySegments(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestIsSensitiveKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"password", true},
+		{"api_token", true},
+		{"X-Auth-Token", true},
+		{"accessToken", true},
+		{"Authorization", true},
+		{"client_secret", true},
+		{"DB.Password", true},
+		{"tokens_used", false},
+		{"tokenizer", false},
+		{"secretary", false},
+		{"passwords_total", false},
+		{"user", false},
+	}
+	for _, tt := range tests {
+		if got := isSensitiveKey(tt.key); got != tt.want {
+			t.Errorf("isSensitiveKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestRedactKeysMatchesSegmentRuns(t *testing.T) {
+	redactMu.RLock()
+	previous := redactKeys
+	redactMu.RUnlock()
+	t.Cleanup(func() {
+		redactMu.Lock()
+		redactKeys = previous
+		redactMu.Unlock()
+	})
+
+	RedactKeys("api_key", " Cookie ")
+	for key, want := range map[string]bool{
+		"X-Api-Key":  true,
+		"apiKey":     true,
+		"Set-Cookie": true,
+		"apikeys":    false,
+		"api":        false,
+		"key_api":    false,
+	} {
+		if got := isSensitiveKey(key); got != want {
+			t.Errorf("isSensitiveKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestRedactReportDataMasksColumnsBySegment(t *testing.T) {
+	data := redactReportData(&ReportData{Tables: map[string]TableData{
+		"Usage": {
+			Headers: []string{"User", "API Token", "Tokens Used"},
+			Rows:    [][]interface{}{{"ana", "abc123", 1200}},
+		},
+	}})
+	row := data.Tables["Usage"].Rows[0]
+	if row[1] != DefaultRedactionMask {
+		t.Errorf("API Token column not masked: %v", row[1])
+	}
+	if fmt.Sprint(row[2]) != "1200" {
+		t.Errorf("Tokens Used column changed: %v (%T)", row[2], row[2])
+	}
+}
@@ -0,0 +1,148 @@
+// EYWA Task Results for Go
+//
+// A robot can close its task with a structured JSON result and the euuids
+// of the files it produced. Downstream robots and users read the result of
+// a finished task through GraphQL instead of parsing logs or reports.
+
+package eywa
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TaskResult is the outcome of a finished task
+type TaskResult struct {
+	EUUID    string      `json:"euuid"`
+	Status   Status      `json:"status"`
+	Message  string      `json:"message,omitempty"`
+	Finished *time.Time  `json:"finished,omitempty"`
+	Result   interface{} `json:"result,omitempty"`
+	Files    []string    `json:"files,omitempty"` // Euuids of the output files
+}
+
+// CloseTaskWithResult closes the task like CloseTask and attaches a result
+// object and the euuids of output files. Registered secrets and value
+// pattern matches in result strings are redacted, but values are not masked
+// by key, so fields like "tokens_used" keep their numbers. A result that
+// cannot be encoded as JSON is sent in its sanitized form.
+//
+// Example:
+//
+//	eywa.CloseTaskWithResult(eywa.SUCCESS, map[string]interface{}{
+//	    "imported": 620,
+//	    "skipped":  3,
+//	}, reportFileUUID)
+func CloseTaskWithResult(status Status, result interface{}, files ...string) error {
+	return closeTask(resultParams(status, result, files))
+}
+
+// resultParams builds task.close params carrying a result and output files
+func resultParams(status Status, result interface{}, files []string) TaskParams {
+	if _, err := json.Marshal(result); err != nil {
+		lifecycleWarning("CloseTaskWithResult: result is not valid JSON, sending sanitized result: %v", err)
+		result = sanitizeValue(result)
+	}
+
+	var outputs []string
+	for _, file := range files {
+		if file != "" {
+			outputs = append(outputs, file)
+		}
+	}
+
+	return TaskParams{
+		Status: status,
+		Result: redactStrings(result),
+		Files:  outputs,
+	}
+}
+
+// GetTaskResult reads the status and result of a task
+//
+// Example:
+//
+//	result, err := eywa.GetTaskResult(childUUID)
+//	if err == nil && result.Status == eywa.SUCCESS {
+//	    var summary ImportSummary
+//	    err = result.Decode(&summary)
+//	}
+func GetTaskResult(taskUUID string) (*TaskResult, error) {
+	query := `
+		query GetTaskResult($uuid: UUID!) {
+			getTask(euuid: $uuid) {
+				euuid
+				status
+				message
+				finished
+				result
+				files {
+					euuid
+				}
+			}
+		}
+	`
+
+	response, err := GraphQL(query, map[string]interface{}{
+		"uuid": taskUUID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read task result: %v", err)
+	}
+
+	data, _ := response["data"].(map[string]interface{})
+	task, ok := data["getTask"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("task not found: %s", taskUUID)
+	}
+	return taskResultFromData(task), nil
+}
+
+// Decode decodes the result into target, which must be a pointer
+func (r *TaskResult) Decode(target interface{}) error {
+	if r.Result == nil {
+		return fmt.Errorf("task %s has no result", r.EUUID)
+	}
+	encoded, err := json.Marshal(r.Result)
+	if err != nil {
+		return fmt.Errorf("cannot encode task result: %v", err)
+	}
+	if err := json.Unmarshal(encoded, target); err != nil {
+		return fmt.Errorf("cannot decode task result: %v", err)
+	}
+	return nil
+}
+
+// taskResultFromData converts a getTask response into a TaskResult
+func taskResultFromData(task map[string]interface{}) *TaskResult {
+	result := &TaskResult{
+		Result:   task["result"],
+		Finished: parseTaskTime(task["finished"]),
+	}
+	result.EUUID, _ = task["euuid"].(string)
+	result.Message, _ = task["message"].(string)
+	status, _ := task["status"].(string)
+	result.Status = Status(status)
+
+	// Results stored as JSON text are decoded
+	if text, ok := result.Result.(string); ok {
+		var decoded interface{}
+		if json.Unmarshal([]byte(text), &decoded) == nil {
+			result.Result = decoded
+		}
+	}
+
+	files, _ := task["files"].([]interface{})
+	for _, file := range files {
+		switch f := file.(type) {
+		case string:
+			result.Files = append(result.Files, f)
+		case map[string]interface{}:
+			if euuid, ok := f["euuid"].(string); ok {
+				result.Files = append(result.Files, euuid)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+package eywa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResultParamsKeepsKeyedValues(t *testing.T) {
+	withLogSettings(t, DefaultLogLimits, "supersecretvalue")
+
+	params := resultParams(SUCCESS, map[string]interface{}{
+		"tokens_used":     1200,
+		"password_resets": 3,
+		"note":            "connected with supersecretvalue",
+	}, []string{"", "f1e2d3c4-b5a6-4978-8a6b-5c4d3e2f1a0b"})
+
+	var summary struct {
+		TokensUsed     int    `json:"tokens_used"`
+		PasswordResets int    `json:"password_resets"`
+		Note           string `json:"note"`
+	}
+	result := &TaskResult{EUUID: "c9d8e7f6-a5b4-4c3d-9e8f-7a6b5c4d3e2f", Result: params.Result}
+	if err := result.Decode(&summary); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if summary.TokensUsed != 1200 || summary.PasswordResets != 3 {
+		t.Errorf("numbers lost: %+v", summary)
+	}
+	if strings.Contains(summary.Note, "supersecretvalue") {
+		t.Errorf("registered secret not redacted: %q", summary.Note)
+	}
+	if len(params.Files) != 1 {
+		t.Errorf("got files %v, want the one non-empty euuid", params.Files)
+	}
+}
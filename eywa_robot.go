@@ -0,0 +1,384 @@
+// EYWA Robot Framework for Go
+//
+// Robot replaces the usual skeleton (open pipe, GetTask, UpdateTask,
+// work, Report, CloseTask) with a list of named steps. The framework
+// connects to EYWA, marks the task as processing, runs the steps in order
+// with per-step retries and timeouts, logs every step with its duration,
+// reports a summary table and closes the task with the matching status.
+
+package eywa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Step outcomes shown in the summary report
+const (
+	StepSucceeded = "SUCCESS"
+	StepFailed    = "ERROR"
+	StepSkipped   = "SKIPPED"
+	StepResumed   = "RESUMED" // Completed by an earlier attempt of the task
+)
+
+// StepFunc is the work done by a robot step
+type StepFunc func(ctx context.Context) error
+
+// RetryPolicy controls how often a failed step is retried
+type RetryPolicy struct {
+	MaxAttempts int                  // Total attempts including the first, values below 2 disable retries
+	Delay       time.Duration        // Wait before the first retry
+	Backoff     float64              // Delay multiplier for each further retry, defaults to 1
+	MaxDelay    time.Duration        // Upper bound for the delay, 0 means unbounded
+	RetryIf     func(err error) bool // Decides whether an error is retried, nil retries all errors
+}
+
+// StepOptions configures a robot step
+type StepOptions struct {
+	Retry    *RetryPolicy  // Retry policy, nil runs the step once
+	Timeout  time.Duration // Limit for a single attempt, 0 means no limit. A timed out attempt must return before it is retried.
+	Optional bool          // A failed optional step is logged but does not stop the robot
+}
+
+// StepResult is the outcome of a robot step
+type StepResult struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Attempts int           `json:"attempts"`
+	Duration time.Duration `json:"-"`
+	Err      error         `json:"-"`
+}
+
+// StepTimeoutError is returned when a step attempt exceeds its timeout
+type StepTimeoutError struct {
+	Step    string
+	Timeout time.Duration
+}
+
+func (e *StepTimeoutError) Error() string {
+	return fmt.Sprintf("step %s timed out after %v", e.Step, e.Timeout)
+}
+
+// Robot runs a sequence of named steps as one task
+type Robot struct {
+	name      string
+	steps     []robotStep
+	resumable bool
+}
+
+type robotStep struct {
+	name    string
+	fn      StepFunc
+	options StepOptions
+}
+
+// NewRobot creates a robot with the given name
+//
+// Example:
+//
+//	robot := eywa.NewRobot("invoice-import")
+//	robot.Step("download", downloadInvoices, &eywa.StepOptions{
+//	    Retry:   &eywa.RetryPolicy{MaxAttempts: 3, Delay: 5 * time.Second, Backoff: 2},
+//	    Timeout: 2 * time.Minute,
+//	})
+//	robot.Step("import", importInvoices, nil)
+//	robot.Step("notify", notifyAccounting, &eywa.StepOptions{Optional: true})
+//	robot.Run()
+func NewRobot(name string) *Robot {
+	return &Robot{name: name}
+}
+
+// Name returns the robot name
+func (r *Robot) Name() string {
+	return r.name
+}
+
+// Step adds a step to the robot. Steps run in the order they are added.
+func (r *Robot) Step(name string, fn StepFunc, options *StepOptions) *Robot {
+	step := robotStep{name: name, fn: fn}
+	if options != nil {
+		step.options = *options
+	}
+	r.steps = append(r.steps, step)
+	return r
+}
+
+// Resumable makes the robot record completed steps in a checkpoint. When
+// EYWA retries the task, steps completed by the earlier attempt are skipped.
+// The checkpoint is cleared once all steps succeed.
+func (r *Robot) Resumable() *Robot {
+	r.resumable = true
+	return r
+}
+
+// Run connects to EYWA, executes the steps and closes the task. It does not
+// return, see Run.
+func (r *Robot) Run() {
+	r.RunWithOptions(nil)
+}
+
+// RunWithOptions is Run with signal handling options, see RunWithOptions
+func (r *Robot) RunWithOptions(options *RunOptions) {
+	RunWithOptions(func(ctx context.Context) error {
+		_, err := r.Execute(ctx)
+		return err
+	}, options)
+}
+
+// Execute marks the task as processing, runs the steps and reports the
+// summary table. It returns the error of the first failed required step;
+// the steps after it are skipped.
+func (r *Robot) Execute(ctx context.Context) ([]StepResult, error) {
+	updateTaskContext(ctx, PROCESSING, nil)
+	logger := WithContext(ctx).WithCoordinates(NewCoordinates(r.name, ""))
+	logger.Info(fmt.Sprintf("Robot %s started", r.name), map[string]interface{}{
+		"steps": len(r.steps),
+	})
+
+	completed := r.loadCompletedSteps(logger)
+
+	results := make([]StepResult, 0, len(r.steps))
+	var failure error
+	for _, step := range r.steps {
+		if failure != nil {
+			results = append(results, StepResult{Name: step.name, Status: StepSkipped})
+			continue
+		}
+		if completed[step.name] {
+			logger.WithStep(step.name).Info(fmt.Sprintf("Step %s completed by an earlier attempt, skipping", step.name), nil)
+			results = append(results, StepResult{Name: step.name, Status: StepResumed})
+			continue
+		}
+
+		result := r.runStep(ctx, logger.WithStep(step.name), step)
+		results = append(results, result)
+		if result.Err != nil && !step.options.Optional {
+			failure = fmt.Errorf("step %s failed: %w", step.name, result.Err)
+			continue
+		}
+		if result.Err == nil && r.resumable {
+			completed[step.name] = true
+			r.saveCompletedSteps(logger, completed)
+		}
+	}
+
+	if failure == nil && r.resumable {
+		if err := clearCheckpoint(ctx, r.checkpointKey()); err != nil {
+			logger.LogErr(WARN, "Cannot clear robot checkpoint", err)
+		}
+	}
+
+	r.report(logger, results, failure)
+	return results, failure
+}
+
+func (r *Robot) checkpointKey() string {
+	return "eywa.robot." + r.name + ".steps"
+}
+
+// loadCompletedSteps reads the steps completed by earlier attempts.
+// Checkpoint failures are logged and the robot starts from the first step.
+func (r *Robot) loadCompletedSteps(logger *Logger) map[string]bool {
+	completed := make(map[string]bool)
+	if !r.resumable {
+		return completed
+	}
+
+	var names []string
+	resumed, err := loadCheckpoint(logger.Context(), r.checkpointKey(), &names)
+	if err != nil {
+		logger.LogErr(WARN, "Cannot load robot checkpoint, starting from the first step", err)
+		return completed
+	}
+	for _, name := range names {
+		completed[name] = true
+	}
+	if resumed {
+		logger.Info(fmt.Sprintf("Robot %s resuming", r.name), map[string]interface{}{
+			"completed_steps": names,
+		})
+	}
+	return completed
+}
+
+// saveCompletedSteps records the completed steps in step order
+func (r *Robot) saveCompletedSteps(logger *Logger, completed map[string]bool) {
+	names := make([]string, 0, len(completed))
+	for _, step := range r.steps {
+		if completed[step.name] {
+			names = append(names, step.name)
+		}
+	}
+	if err := saveCheckpoint(logger.Context(), r.checkpointKey(), names); err != nil {
+		logger.LogErr(WARN, "Cannot save robot checkpoint", err)
+	}
+}
+
+// runStep runs one step with its retry policy and logs the outcome
+func (r *Robot) runStep(ctx context.Context, logger *Logger, step robotStep) StepResult {
+	result := StepResult{Name: step.name}
+	policy := step.options.Retry
+	maxAttempts := 1
+	if policy != nil && policy.MaxAttempts > 1 {
+		maxAttempts = policy.MaxAttempts
+	}
+
+	logger.Info(fmt.Sprintf("Step %s started", step.name), nil)
+	start := time.Now()
+	delay := time.Duration(0)
+	if policy != nil {
+		delay = policy.Delay
+	}
+
+	for attempt := 1; ; attempt++ {
+		var running <-chan error
+		result.Attempts = attempt
+		running, result.Err = runStepAttempt(ctx, step)
+		if result.Err == nil {
+			break
+		}
+		if attempt >= maxAttempts || (policy.RetryIf != nil && !policy.RetryIf(result.Err)) {
+			break
+		}
+
+		// Never run the same step twice at once
+		if running != nil {
+			logger.Warn(fmt.Sprintf("Step %s timed out, waiting for the attempt to return before retrying", step.name), map[string]interface{}{
+				"attempt": attempt,
+			})
+			select {
+			case <-running:
+			case <-ctx.Done():
+				result.Err = ctx.Err()
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		logger.Warn(fmt.Sprintf("Step %s failed, retrying", step.name), map[string]interface{}{
+			"attempt":  attempt,
+			"error":    result.Err.Error(),
+			"delay_ms": delay.Milliseconds(),
+		})
+		if err := sleepContext(ctx, delay); err != nil {
+			result.Err = err
+			break
+		}
+		delay = nextRetryDelay(policy, delay)
+	}
+
+	result.Duration = time.Since(start)
+	duration := int(result.Duration.Milliseconds())
+	data := map[string]interface{}{"attempts": result.Attempts}
+
+	if result.Err == nil {
+		result.Status = StepSucceeded
+		logger.Log(INFO, fmt.Sprintf("Step %s finished", step.name), data, &duration, nil, nil)
+		return result
+	}
+
+	result.Status = StepFailed
+	level := LOG_ERROR
+	if step.options.Optional {
+		level = WARN
+		data["optional"] = true
+	}
+	details := errorDetails(result.Err, 0)
+	var panicErr *PanicError
+	if errors.As(result.Err, &panicErr) {
+		details["stack"] = panicErr.Stack
+	}
+	for key, value := range data {
+		details[key] = value
+	}
+	logger.Log(level, fmt.Sprintf("Step %s failed: %v", step.name, result.Err), details, &duration, nil, nil)
+	return result
+}
+
+// runStepAttempt runs a single attempt, enforcing the timeout and turning
+// a panic into a *PanicError. A step that ignores its context keeps running
+// in the background after a timeout; running then delivers its outcome.
+func runStepAttempt(ctx context.Context, step robotStep) (running <-chan error, err error) {
+	attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+	if step.options.Timeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(ctx, step.options.Timeout)
+	}
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runProtected(attemptCtx, step.fn)
+	}()
+
+	select {
+	case err := <-done:
+		return nil, err
+	case <-attemptCtx.Done():
+		if ctx.Err() != nil {
+			return done, ctx.Err()
+		}
+		return done, &StepTimeoutError{Step: step.name, Timeout: step.options.Timeout}
+	}
+}
+
+func nextRetryDelay(policy *RetryPolicy, delay time.Duration) time.Duration {
+	if policy.Backoff > 1 {
+		delay = time.Duration(float64(delay) * policy.Backoff)
+	}
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	return delay
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// report sends the summary table of step outcomes
+func (r *Robot) report(logger *Logger, results []StepResult, failure error) {
+	rows := make([][]interface{}, len(results))
+	for i, result := range results {
+		message := ""
+		if result.Err != nil {
+			message = result.Err.Error()
+		}
+		duration := ""
+		if result.Status == StepSucceeded || result.Status == StepFailed {
+			duration = result.Duration.Round(time.Millisecond).String()
+		}
+		rows[i] = []interface{}{result.Name, result.Status, result.Attempts, duration, message}
+	}
+
+	message := fmt.Sprintf("Robot %s completed", r.name)
+	if failure != nil {
+		message = fmt.Sprintf("Robot %s failed", r.name)
+	}
+
+	err := logger.Report(message, &ReportOptions{
+		Data: &ReportData{
+			Tables: map[string]TableData{
+				"Steps": {
+					Headers: []string{"Step", "Status", "Attempts", "Duration", "Error"},
+					Rows:    rows,
+				},
+			},
+		},
+	})
+	if err != nil {
+		logger.LogErr(WARN, "Cannot send robot summary report", err)
+	}
+}
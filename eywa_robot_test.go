@@ -0,0 +1,50 @@
+package eywa
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunStepWaitsForTimedOutAttempt(t *testing.T) {
+	EnableDevMode(&DevOptions{Output: io.Discard, NoColor: true})
+
+	var running, overlaps, calls int32
+	step := robotStep{
+		name: "slow",
+		fn: func(ctx context.Context) error {
+			if atomic.AddInt32(&running, 1) > 1 {
+				atomic.AddInt32(&overlaps, 1)
+			}
+			defer atomic.AddInt32(&running, -1)
+
+			// Ignores ctx like a blocking call would
+			time.Sleep(50 * time.Millisecond)
+			if atomic.AddInt32(&calls, 1) < 3 {
+				return errors.New("not yet")
+			}
+			return nil
+		},
+		options: StepOptions{
+			Timeout: 10 * time.Millisecond,
+			Retry:   &RetryPolicy{MaxAttempts: 3},
+		},
+	}
+
+	robot := NewRobot("test")
+	result := robot.runStep(context.Background(), WithContext(context.Background()), step)
+
+	if overlaps != 0 {
+		t.Errorf("attempts overlapped %d times", overlaps)
+	}
+	if result.Attempts != 3 {
+		t.Errorf("got %d attempts, want 3", result.Attempts)
+	}
+	var timeout *StepTimeoutError
+	if !errors.As(result.Err, &timeout) {
+		t.Errorf("got %v, want the last attempt to time out", result.Err)
+	}
+}
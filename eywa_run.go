@@ -0,0 +1,213 @@
+// EYWA Robot Entry Point for Go
+//
+// Run wraps the usual robot skeleton (open the pipe, do the work, close the
+// task) and guarantees that the runtime always sees a terminal status, even
+// when the robot panics or the host stops it with SIGTERM or SIGINT.
+
+package eywa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultSignalGracePeriod is how long a robot may keep running after a
+// termination signal before its task is closed
+const DefaultSignalGracePeriod = 10 * time.Second
+
+// RunOptions configures RunWithOptions
+type RunOptions struct {
+	GracePeriod    time.Duration       `json:"grace_period,omitempty"`    // Time to finish after a signal, defaults to DefaultSignalGracePeriod
+	SignalStatus   Status              `json:"signal_status,omitempty"`   // Close status after a signal, defaults to ERROR
+	DisableSignals bool                `json:"disable_signals,omitempty"` // Leave SIGTERM and SIGINT to the robot
+	Timeout        time.Duration       `json:"timeout,omitempty"`         // Robot default when the task sets no deadline or timeout
+	Environment    *EnvironmentOptions `json:"environment,omitempty"`     // Report the environment at start, see ReportEnvironment
+}
+
+// PanicError is reported when a robot function panics
+type PanicError struct {
+	Value interface{}
+	Stack []string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Run connects to EYWA, runs fn and closes the task based on its outcome:
+// nil closes with SUCCESS, an error is logged and closes with ERROR, and a
+// panic is recovered, logged as EXCEPTION with its stack and closes with ERROR.
+// Run does not return.
+//
+// Panics in goroutines started by fn cannot be recovered and still crash
+// the process.
+//
+// Example:
+//
+//	func main() {
+//	    eywa.Run(func(ctx context.Context) error {
+//	        eywa.Info("Robot started", nil)
+//	        return importInvoices(ctx)
+//	    })
+//	}
+func Run(fn func(ctx context.Context) error) {
+	RunWithOptions(fn, nil)
+}
+
+// RunWithOptions is Run with signal handling and deadline options. On
+// SIGTERM or SIGINT the signal is logged and the context of fn is
+// cancelled. fn then has the grace period to return, e.g. to let uploads
+// and GraphQL calls finish, before the task is closed with the signal
+// status. A second signal closes the task immediately.
+//
+// The context of fn carries the trace imported from the task, see
+// ImportTrace, and the task deadline, see TaskDeadline, or the Timeout
+// option. Exceeding it closes the task with ERROR the same way.
+//
+// Example:
+//
+//	eywa.RunWithOptions(importInvoices, &eywa.RunOptions{
+//	    GracePeriod: 30 * time.Second,
+//	})
+func RunWithOptions(fn func(ctx context.Context) error, options *RunOptions) {
+	opts := RunOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.GracePeriod <= 0 {
+		opts.GracePeriod = DefaultSignalGracePeriod
+	}
+	if opts.SignalStatus == "" {
+		opts.SignalStatus = ERROR
+	}
+
+	go OpenPipe()
+
+	// Continue the trace passed in the task data, e.g. by SpawnTask
+	traceCtx, err := ImportTrace(context.Background())
+	if err != nil {
+		LogErr(DEBUG, "No task trace imported", err)
+	}
+
+	if opts.Environment != nil {
+		if err := ReportEnvironment(opts.Environment); err != nil {
+			LogErr(WARN, "Cannot report environment", err)
+		}
+	}
+
+	var signals chan os.Signal
+	if !opts.DisableSignals {
+		signals = make(chan os.Signal, 2)
+		signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+		defer signal.Stop(signals)
+	}
+
+	ctx, cancel := context.WithCancel(traceCtx)
+	defer cancel()
+
+	start := time.Now()
+	deadline, hasDeadline := runDeadline(opts, start)
+	if hasDeadline {
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+		go watchDeadline(ctx, start, deadline)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runProtected(ctx, fn)
+	}()
+
+	select {
+	case err := <-done:
+		if hasDeadline && ctx.Err() == context.DeadlineExceeded {
+			stopOnDeadline(deadline, closedRun(err), opts)
+			return
+		}
+		finishRun(err)
+	case sig := <-signals:
+		stopOnSignal(sig, cancel, done, signals, opts)
+	case <-ctx.Done():
+		stopOnDeadline(deadline, done, opts)
+	}
+}
+
+// closedRun returns a channel that already holds the result of fn
+func closedRun(err error) <-chan error {
+	done := make(chan error, 1)
+	done <- err
+	return done
+}
+
+// runProtected runs fn, converting a panic into a *PanicError
+func runProtected(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{
+				Value: r,
+				Stack: callerStack(1),
+			}
+		}
+	}()
+	return fn(ctx)
+}
+
+// stopOnSignal cancels the robot, waits for it within the grace period and
+// closes the task with the signal status
+func stopOnSignal(sig os.Signal, cancel context.CancelFunc, done <-chan error, signals <-chan os.Signal, opts RunOptions) {
+	Warn(fmt.Sprintf("Received %v, stopping robot", sig), map[string]interface{}{
+		"signal":          sig.String(),
+		"grace_period_ms": opts.GracePeriod.Milliseconds(),
+	})
+	cancel()
+
+	timer := time.NewTimer(opts.GracePeriod)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		if err != nil && !errors.Is(err, context.Canceled) {
+			LogErr(WARN, fmt.Sprintf("Robot stopped after %v: %v", sig, err), err)
+		} else {
+			Info(fmt.Sprintf("Robot stopped after %v", sig), nil)
+		}
+	case <-timer.C:
+		Error(fmt.Sprintf("Robot did not stop within %v after %v", opts.GracePeriod, sig), nil)
+	case second := <-signals:
+		Error(fmt.Sprintf("Received %v again, closing task immediately", second), nil)
+	}
+
+	CloseTaskWithResult(opts.SignalStatus, map[string]interface{}{
+		"interrupted": true,
+		"signal":      sig.String(),
+	})
+}
+
+// finishRun logs the outcome of a robot function and closes the task
+func finishRun(err error) {
+	if err == nil {
+		CloseTask(SUCCESS)
+		return
+	}
+
+	if panicErr, ok := err.(*PanicError); ok {
+		data := map[string]interface{}{
+			"panic": fmt.Sprintf("%v", panicErr.Value),
+			"type":  fmt.Sprintf("%T", panicErr.Value),
+			"stack": panicErr.Stack,
+		}
+		if cause, ok := panicErr.Value.(error); ok {
+			data["chain"] = errorChain(cause, 0)
+		}
+		Exception(fmt.Sprintf("Robot panicked: %v", panicErr.Value), data)
+	} else {
+		LogErr(LOG_ERROR, fmt.Sprintf("Robot failed: %v", err), err)
+	}
+
+	CloseTask(ERROR)
+}
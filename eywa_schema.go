@@ -0,0 +1,764 @@
+// EYWA Task Input Schemas for Go
+//
+// Robots declare the shape of their task data as a JSON Schema (a subset of
+// draft 2020-12) or derive one from a Go struct, and ValidateTaskInput
+// checks the task data before any work starts.
+//
+// Supported keywords: type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, uniqueItems, minimum,
+// maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, minLength,
+// maxLength, pattern, format, allOf, anyOf, oneOf, not, $defs and local
+// $ref ("#" and "#/$defs/<name>"). Patterns use Go regexp syntax.
+
+package eywa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema is a JSON Schema describing task data
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 SchemaType         `json:"type,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Const                interface{}        `json:"const,omitempty"` // A null const is not supported
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	UniqueItems          bool               `json:"uniqueItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MultipleOf           *float64           `json:"multipleOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"` // date-time, date, email, uri, uuid, ipv4, ipv6
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Not                  *Schema            `json:"not,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// SchemaType lists the JSON types a value may have. It decodes from a
+// single type name or an array of names.
+type SchemaType []string
+
+// UnmarshalJSON accepts "string" as well as ["string", "null"]
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = SchemaType{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("schema type must be a string or an array of strings")
+	}
+	*t = list
+	return nil
+}
+
+// MarshalJSON encodes a single type as a plain string
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON accepts boolean schemas: true allows anything, false nothing
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch strings.TrimSpace(string(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{Not: &Schema{}}
+		return nil
+	}
+	type plain Schema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// SchemaViolation is a value that does not match its schema
+type SchemaViolation struct {
+	Path    string `json:"path"` // JSON path of the value, e.g. "$.invoices[2].amount"
+	Message string `json:"message"`
+}
+
+func (v SchemaViolation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+// SchemaViolations is the error ValidateTaskInput returns for invalid input
+type SchemaViolations []SchemaViolation
+
+func (v SchemaViolations) Error() string {
+	messages := make([]string, len(v))
+	for i, violation := range v {
+		messages[i] = violation.Error()
+	}
+	return "invalid task input: " + strings.Join(messages, "; ")
+}
+
+// ParseSchema parses a JSON Schema document
+//
+// Example:
+//
+//	schema, err := eywa.ParseSchema([]byte(`{
+//	    "type": "object",
+//	    "required": ["year"],
+//	    "properties": {"year": {"type": "integer", "minimum": 2000}}
+//	}`))
+func ParseSchema(data []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	if err := schema.compile(&schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// SchemaFromStruct derives a schema from a Go value (usually a struct)
+// following encoding/json field names. Constraints come from the eywa tag:
+// required, min=, max=, minLength=, maxLength=, minItems=, maxItems=,
+// pattern=, format= and enum= with values separated by "|". Patterns must
+// not contain commas.
+//
+// Example:
+//
+//	type ImportInput struct {
+//	    Year    int      `json:"year" eywa:"required,min=2000"`
+//	    Mode    string   `json:"mode" eywa:"enum=full|delta"`
+//	    Sources []string `json:"sources" eywa:"minItems=1"`
+//	}
+//	schema, err := eywa.SchemaFromStruct(ImportInput{})
+func SchemaFromStruct(v interface{}) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("cannot derive schema from nil")
+	}
+	schema, err := schemaForType(t, make(map[reflect.Type]bool))
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.compile(schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// Validate checks value against the schema and returns every violation
+func (s *Schema) Validate(value interface{}) []SchemaViolation {
+	validator := &schemaValidator{root: s}
+	validator.validate(s, normalizeJSON(value), "$")
+	return validator.violations
+}
+
+// ValidateTaskInput validates the current task data against schema. Each
+// violation is logged with its JSON path and the task is closed with ERROR,
+// which exits the process. When the task cannot be closed, e.g. because it
+// was closed already, the violations are returned as SchemaViolations so
+// the robot does not continue with the rejected input. In service mode use
+// TaskSession.ValidateInput, which closes only the session's task.
+//
+// Example:
+//
+//	schema, _ := eywa.SchemaFromStruct(ImportInput{})
+//	if err := eywa.ValidateTaskInput(schema); err != nil {
+//	    return err
+//	}
+func ValidateTaskInput(schema *Schema) error {
+	return validateTaskInput(context.Background(), schema, CloseTask)
+}
+
+// validateTaskInput validates the data of the task of ctx, logs the
+// violations and closes the task with closeTask
+func validateTaskInput(ctx context.Context, schema *Schema, closeTask func(Status) error) error {
+	task, err := currentTaskFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	violations := schema.Validate(task.Raw["data"])
+	if len(violations) == 0 {
+		return nil
+	}
+
+	logger := WithContext(ctx)
+	for _, violation := range violations {
+		logger.Error("Invalid task input: "+violation.Error(), map[string]interface{}{
+			"path":    violation.Path,
+			"message": violation.Message,
+		})
+	}
+	logger.Error("Task input validation failed", map[string]interface{}{
+		"violations": len(violations),
+	})
+	if err := closeTask(ERROR); err != nil {
+		return fmt.Errorf("%w; cannot close task: %v", SchemaViolations(violations), err)
+	}
+	return SchemaViolations(violations)
+}
+
+// compile checks patterns and references, root resolves $ref
+func (s *Schema) compile(root *Schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Pattern != "" && s.pattern == nil {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid schema pattern %q: %v", s.Pattern, err)
+		}
+		s.pattern = pattern
+	}
+	if s.Ref != "" {
+		if _, err := root.resolve(s.Ref); err != nil {
+			return err
+		}
+	}
+	for _, t := range s.Type {
+		switch t {
+		case "null", "boolean", "integer", "number", "string", "array", "object":
+		default:
+			return fmt.Errorf("unknown schema type %q", t)
+		}
+	}
+
+	children := []*Schema{s.AdditionalProperties, s.Items, s.Not}
+	children = append(children, s.AllOf...)
+	children = append(children, s.AnyOf...)
+	children = append(children, s.OneOf...)
+	for _, child := range s.Properties {
+		children = append(children, child)
+	}
+	for _, child := range s.Defs {
+		children = append(children, child)
+	}
+	for _, child := range children {
+		if err := child.compile(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve finds a local reference within the root schema
+func (s *Schema) resolve(ref string) (*Schema, error) {
+	if ref == "#" {
+		return s, nil
+	}
+	name := strings.TrimPrefix(ref, "#/$defs/")
+	if name == ref || s.Defs[name] == nil {
+		return nil, fmt.Errorf("unresolvable schema reference %q", ref)
+	}
+	return s.Defs[name], nil
+}
+
+// schemaValidator collects violations while walking a value
+type schemaValidator struct {
+	root       *Schema
+	violations []SchemaViolation
+	refs       int // $ref hops followed for the current value
+}
+
+func (v *schemaValidator) fail(path, format string, args ...interface{}) {
+	v.violations = append(v.violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// matches reports whether value satisfies s without recording violations
+func (v *schemaValidator) matches(s *Schema, value interface{}, path string) bool {
+	probe := &schemaValidator{root: v.root, refs: v.refs}
+	probe.validate(s, value, path)
+	return len(probe.violations) == 0
+}
+
+func (v *schemaValidator) validate(s *Schema, value interface{}, path string) {
+	if s == nil {
+		return
+	}
+
+	if s.Ref != "" {
+		target, err := v.root.resolve(s.Ref)
+		if err != nil {
+			v.fail(path, "%v", err)
+			return
+		}
+		if v.refs >= maxSchemaRefs {
+			v.fail(path, "schema $ref recursion exceeds %d levels", maxSchemaRefs)
+			return
+		}
+		v.refs++
+		v.validate(target, value, path)
+		v.refs--
+	}
+
+	if len(s.Type) > 0 && !matchesType(s.Type, value) {
+		v.fail(path, "must be %s, got %s", strings.Join(s.Type, " or "), jsonTypeOf(value))
+		return
+	}
+	if s.Enum != nil && !containsJSON(s.Enum, value) {
+		v.fail(path, "must be one of %s", compactJSON(s.Enum))
+	}
+	if s.Const != nil && !jsonEqual(s.Const, value) {
+		v.fail(path, "must be %s", compactJSON(s.Const))
+	}
+
+	switch x := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(s, x, path)
+	case []interface{}:
+		v.validateArray(s, x, path)
+	case string:
+		v.validateString(s, x, path)
+	case float64:
+		v.validateNumber(s, x, path)
+	}
+
+	for _, sub := range s.AllOf {
+		v.validate(sub, value, path)
+	}
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, sub := range s.AnyOf {
+			if v.matches(sub, value, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "must match at least one anyOf schema")
+		}
+	}
+	if len(s.OneOf) > 0 {
+		count := 0
+		for _, sub := range s.OneOf {
+			if v.matches(sub, value, path) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.fail(path, "must match exactly one oneOf schema, matched %d", count)
+		}
+	}
+	if s.Not != nil && v.matches(s.Not, value, path) {
+		if isEmptySchema(s.Not) {
+			v.fail(path, "is not allowed")
+		} else {
+			v.fail(path, "must not match the not schema")
+		}
+	}
+}
+
+// validateChild validates a nested value, which starts a new $ref chain
+func (v *schemaValidator) validateChild(s *Schema, value interface{}, path string) {
+	refs := v.refs
+	v.refs = 0
+	v.validate(s, value, path)
+	v.refs = refs
+}
+
+func (v *schemaValidator) validateObject(s *Schema, object map[string]interface{}, path string) {
+	for _, name := range s.Required {
+		if _, exists := object[name]; !exists {
+			v.fail(childPath(path, name), "is required")
+		}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if property, ok := s.Properties[key]; ok {
+			v.validateChild(property, object[key], childPath(path, key))
+		} else if s.AdditionalProperties != nil {
+			v.validateChild(s.AdditionalProperties, object[key], childPath(path, key))
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(s *Schema, items []interface{}, path string) {
+	if s.MinItems != nil && len(items) < *s.MinItems {
+		v.fail(path, "must have at least %d items, got %d", *s.MinItems, len(items))
+	}
+	if s.MaxItems != nil && len(items) > *s.MaxItems {
+		v.fail(path, "must have at most %d items, got %d", *s.MaxItems, len(items))
+	}
+	if s.UniqueItems {
+		seen := make(map[string]int, len(items))
+		for i, item := range items {
+			key := compactJSON(item)
+			if first, exists := seen[key]; exists {
+				v.fail(fmt.Sprintf("%s[%d]", path, i), "duplicates item %d", first)
+				continue
+			}
+			seen[key] = i
+		}
+	}
+	if s.Items != nil {
+		for i, item := range items {
+			v.validateChild(s.Items, item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(s *Schema, value, path string) {
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		v.fail(path, "must be at least %d characters, got %d", *s.MinLength, length)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.fail(path, "must be at most %d characters, got %d", *s.MaxLength, length)
+	}
+	if s.Pattern != "" {
+		pattern := s.pattern
+		if pattern == nil {
+			var err error
+			if pattern, err = regexp.Compile(s.Pattern); err != nil {
+				v.fail(path, "invalid schema pattern %q", s.Pattern)
+				return
+			}
+		}
+		if !pattern.MatchString(value) {
+			v.fail(path, "must match pattern %s", s.Pattern)
+		}
+	}
+	if s.Format != "" && !matchesFormat(s.Format, value) {
+		v.fail(path, "must be a valid %s", s.Format)
+	}
+}
+
+func (v *schemaValidator) validateNumber(s *Schema, value float64, path string) {
+	if s.Minimum != nil && value < *s.Minimum {
+		v.fail(path, "must be >= %v, got %v", *s.Minimum, value)
+	}
+	if s.Maximum != nil && value > *s.Maximum {
+		v.fail(path, "must be <= %v, got %v", *s.Maximum, value)
+	}
+	if s.ExclusiveMinimum != nil && value <= *s.ExclusiveMinimum {
+		v.fail(path, "must be > %v, got %v", *s.ExclusiveMinimum, value)
+	}
+	if s.ExclusiveMaximum != nil && value >= *s.ExclusiveMaximum {
+		v.fail(path, "must be < %v, got %v", *s.ExclusiveMaximum, value)
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		quotient := value / *s.MultipleOf
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			v.fail(path, "must be a multiple of %v", *s.MultipleOf)
+		}
+	}
+}
+
+// maxSchemaRefs stops $ref cycles that never descend into the value,
+// like {"$ref": "#"}. Nested data may be arbitrarily deep.
+const maxSchemaRefs = 64
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// matchesFormat checks known formats, unknown formats always match
+func matchesFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "email":
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	case "uri":
+		parsed, err := url.Parse(value)
+		return err == nil && parsed.IsAbs()
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	case "ipv6":
+		return net.ParseIP(value) != nil && strings.Contains(value, ":")
+	}
+	return true
+}
+
+func matchesType(types SchemaType, value interface{}) bool {
+	actual := jsonTypeOf(value)
+	for _, t := range types {
+		if t == actual {
+			return true
+		}
+		if t == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTypeOf names the JSON type of a normalized value. Whole numbers are
+// reported as integer.
+func jsonTypeOf(value interface{}) string {
+	switch x := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if x == math.Trunc(x) && !math.IsInf(x, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// normalizeJSON converts a Go value into the types encoding/json decodes into
+func normalizeJSON(value interface{}) interface{} {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var generic interface{}
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return value
+	}
+	return generic
+}
+
+func jsonEqual(a, b interface{}) bool {
+	return compactJSON(normalizeJSON(a)) == compactJSON(normalizeJSON(b))
+}
+
+func containsJSON(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if jsonEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptySchema(s *Schema) bool {
+	return reflect.DeepEqual(*s, Schema{})
+}
+
+// childPath appends an object key to a JSON path
+func childPath(path, key string) string {
+	for i, r := range key {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return fmt.Sprintf("%s[%q]", path, key)
+		}
+	}
+	if key == "" {
+		return path + `[""]`
+	}
+	return path + "." + key
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaForType maps a Go type to a schema, visiting guards recursive types
+func schemaForType(t reflect.Type, visiting map[reflect.Type]bool) (*Schema, error) {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	schema := &Schema{}
+	switch {
+	case t == timeType:
+		schema.Type = SchemaType{"string"}
+		schema.Format = "date-time"
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		// Custom encoding, any value is accepted
+		return schema, nil
+	default:
+		switch t.Kind() {
+		case reflect.Bool:
+			schema.Type = SchemaType{"boolean"}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			schema.Type = SchemaType{"integer"}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			schema.Type = SchemaType{"integer"}
+			zero := 0.0
+			schema.Minimum = &zero
+		case reflect.Float32, reflect.Float64:
+			schema.Type = SchemaType{"number"}
+		case reflect.String:
+			schema.Type = SchemaType{"string"}
+		case reflect.Slice, reflect.Array:
+			if t.Elem().Kind() == reflect.Uint8 {
+				schema.Type = SchemaType{"string"}
+				break
+			}
+			schema.Type = SchemaType{"array"}
+			items, err := schemaForType(t.Elem(), visiting)
+			if err != nil {
+				return nil, err
+			}
+			schema.Items = items
+			nullable = nullable || t.Kind() == reflect.Slice
+		case reflect.Map:
+			schema.Type = SchemaType{"object"}
+			values, err := schemaForType(t.Elem(), visiting)
+			if err != nil {
+				return nil, err
+			}
+			schema.AdditionalProperties = values
+			nullable = true
+		case reflect.Struct:
+			if visiting[t] {
+				// Recursive type, accept any value below this point
+				return &Schema{}, nil
+			}
+			visiting[t] = true
+			defer delete(visiting, t)
+
+			schema.Type = SchemaType{"object"}
+			schema.Properties = make(map[string]*Schema)
+			for _, field := range jsonFields(t) {
+				property, err := schemaForType(field.typ, visiting)
+				if err != nil {
+					return nil, err
+				}
+				if err := applySchemaTag(property, field.tag); err != nil {
+					return nil, fmt.Errorf("field %s: %v", field.name, err)
+				}
+				schema.Properties[field.name] = property
+				if field.required {
+					schema.Required = append(schema.Required, field.name)
+				}
+			}
+		case reflect.Interface:
+			return schema, nil
+		default:
+			return nil, fmt.Errorf("cannot derive schema for %s", t)
+		}
+	}
+
+	if nullable && len(schema.Type) > 0 {
+		schema.Type = append(schema.Type, "null")
+	}
+	return schema, nil
+}
+
+// applySchemaTag adds constraints from an eywa struct tag
+func applySchemaTag(schema *Schema, tag string) error {
+	for _, option := range strings.Split(tag, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(option), "=")
+		if !found {
+			continue
+		}
+
+		var err error
+		switch key {
+		case "min":
+			schema.Minimum, err = parseSchemaFloat(key, value)
+		case "max":
+			schema.Maximum, err = parseSchemaFloat(key, value)
+		case "minLength":
+			schema.MinLength, err = parseSchemaInt(key, value)
+		case "maxLength":
+			schema.MaxLength, err = parseSchemaInt(key, value)
+		case "minItems":
+			schema.MinItems, err = parseSchemaInt(key, value)
+		case "maxItems":
+			schema.MaxItems, err = parseSchemaInt(key, value)
+		case "pattern":
+			schema.Pattern = value
+		case "format":
+			schema.Format = value
+		case "enum":
+			for _, item := range strings.Split(value, "|") {
+				schema.Enum = append(schema.Enum, schemaEnumValue(schema.Type, item))
+			}
+			// Nil pointers, slices and maps are encoded as null
+			if containsString(schema.Type, "null") {
+				schema.Enum = append(schema.Enum, nil)
+			}
+		default:
+			err = fmt.Errorf("unknown schema option %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaEnumValue converts a tag enum value to the field's JSON type
+func schemaEnumValue(types SchemaType, value string) interface{} {
+	for _, t := range types {
+		switch t {
+		case "integer", "number":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				return f
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(value); err == nil {
+				return b
+			}
+		}
+	}
+	return value
+}
+
+func parseSchemaFloat(key, value string) (*float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number: %q", key, value)
+	}
+	return &f, nil
+}
+
+func parseSchemaInt(key, value string) (*int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative integer: %q", key, value)
+	}
+	return &n, nil
+}
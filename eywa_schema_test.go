@@ -0,0 +1,134 @@
+package eywa
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func mustParseSchema(t *testing.T, document string) *Schema {
+	t.Helper()
+	schema, err := ParseSchema([]byte(document))
+	if err != nil {
+		t.Fatalf("ParseSchema: %v", err)
+	}
+	return schema
+}
+
+func decodeJSON(t *testing.T, document string) interface{} {
+	t.Helper()
+	var value interface{}
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		t.Fatalf("invalid test JSON: %v", err)
+	}
+	return value
+}
+
+// nestedObject builds {"child":{"child":...}} depth levels deep
+func nestedObject(depth int) string {
+	return strings.Repeat(`{"child":`, depth) + "{}" + strings.Repeat("}", depth)
+}
+
+func TestSchemaValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		value  string
+		want   []string // Paths of the expected violations
+	}{
+		{"type", `{"type":"integer"}`, `"x"`, []string{"$"}},
+		{"type list", `{"type":["string","null"]}`, `null`, nil},
+		{"required and nested", `{"type":"object","required":["year"],"properties":{"lines":{"items":{"type":"object","properties":{"amount":{"minimum":0}}}}}}`,
+			`{"lines":[{"amount":1},{"amount":-1}]}`, []string{"$.year", "$.lines[1].amount"}},
+		{"enum", `{"enum":["a","b"]}`, `"c"`, []string{"$"}},
+		{"format", `{"type":"string","format":"uuid"}`, `"not-a-uuid"`, []string{"$"}},
+		{"oneOf", `{"oneOf":[{"type":"integer"},{"type":"number"}]}`, `1`, []string{"$"}},
+		{"deep data under $ref", `{"type":"object","properties":{"child":{"$ref":"#"}}}`, nestedObject(70), nil},
+		{"deep invalid data under $ref", `{"type":"object","properties":{"child":{"$ref":"#"}}}`,
+			strings.Repeat(`{"child":`, 70) + "1" + strings.Repeat("}", 70), []string{"$" + strings.Repeat(".child", 70)}},
+		{"$ref cycle", `{"$ref":"#"}`, `{}`, []string{"$"}},
+		{"$defs", `{"$defs":{"year":{"type":"integer","minimum":2000}},"properties":{"year":{"$ref":"#/$defs/year"}}}`,
+			`{"year":1999}`, []string{"$.year"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := mustParseSchema(t, tt.schema).Validate(decodeJSON(t, tt.value))
+
+			var got []string
+			for _, violation := range violations {
+				got = append(got, violation.Path)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("violations at %v, want %v (%v)", got, tt.want, violations)
+			}
+		})
+	}
+}
+
+func TestSchemaFromStructNilPointers(t *testing.T) {
+	type input struct {
+		Mode  *string `json:"mode" eywa:"enum=full|delta"`
+		Since *string `json:"since" eywa:"format=date"`
+		Year  int     `json:"year" eywa:"required,min=2000"`
+	}
+	schema, err := SchemaFromStruct(input{})
+	if err != nil {
+		t.Fatalf("SchemaFromStruct: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  int
+	}{
+		{"nil pointers", `{"mode":null,"since":null,"year":2024}`, 0},
+		{"valid values", `{"mode":"delta","since":"2024-01-31","year":2024}`, 0},
+		{"invalid values", `{"mode":"partial","since":"yesterday","year":1999}`, 3},
+		{"missing required", `{}`, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := schema.Validate(decodeJSON(t, tt.value))
+			if len(violations) != tt.want {
+				t.Errorf("got %d violations, want %d: %v", len(violations), tt.want, violations)
+			}
+		})
+	}
+}
+
+func TestValidateTaskInputReturnsViolations(t *testing.T) {
+	taskMu.Lock()
+	previousTask := currentTask
+	currentTask = &Task{
+		EUUID: "c9d8e7f6-a5b4-4c3d-9e8f-7a6b5c4d3e2f",
+		Raw:   map[string]interface{}{"data": map[string]interface{}{"year": "x"}},
+	}
+	taskMu.Unlock()
+
+	// A closed task makes CloseTask fail instead of exiting
+	previousLifecycle := lifecycle
+	lifecycle = &taskLifecycle{closed: true}
+
+	defer func() {
+		taskMu.Lock()
+		currentTask = previousTask
+		taskMu.Unlock()
+		lifecycle = previousLifecycle
+	}()
+
+	schema := mustParseSchema(t, `{"properties":{"year":{"type":"integer"}}}`)
+	err := ValidateTaskInput(schema)
+
+	var violations SchemaViolations
+	if !errors.As(err, &violations) {
+		t.Fatalf("got %v, want SchemaViolations", err)
+	}
+	if len(violations) != 1 || violations[0].Path != "$.year" {
+		t.Errorf("unexpected violations: %v", violations)
+	}
+	if !strings.Contains(err.Error(), ErrTaskClosed.Error()) {
+		t.Errorf("close error not passed on: %v", err)
+	}
+}
@@ -0,0 +1,416 @@
+// EYWA Safe Serialization for Go
+//
+// Log data is arbitrary Go values. Before it is sent, it is converted into
+// plain JSON types so that a single unencodable value cannot drop the
+// whole entry:
+// - channels, funcs and complex numbers become descriptive placeholders
+// - reference cycles are cut with a "[cycle ...]" marker
+// - NaN and infinite floats are sent as strings
+// - oversized strings and arrays are truncated with markers
+// - entries above the maximum size are replaced by a truncated preview
+
+package eywa
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// LogLimits bounds the size of log entries
+type LogLimits struct {
+	MaxStringLength int `json:"max_string_length"` // Longest string value in bytes
+	MaxArrayLength  int `json:"max_array_length"`  // Most elements kept per array or map
+	MaxDepth        int `json:"max_depth"`         // Deepest nesting level kept
+	MaxEntrySize    int `json:"max_entry_size"`    // Largest encoded log entry in bytes
+}
+
+// DefaultLogLimits are the limits used unless SetLogLimits is called
+var DefaultLogLimits = LogLimits{
+	MaxStringLength: 16 * 1024,
+	MaxArrayLength:  1000,
+	MaxDepth:        32,
+	MaxEntrySize:    256 * 1024,
+}
+
+var (
+	limitsMu  sync.RWMutex
+	logLimits = DefaultLogLimits
+)
+
+// SetLogLimits replaces the log size limits. Zero fields keep their defaults.
+func SetLogLimits(limits LogLimits) {
+	if limits.MaxStringLength <= 0 {
+		limits.MaxStringLength = DefaultLogLimits.MaxStringLength
+	}
+	if limits.MaxArrayLength <= 0 {
+		limits.MaxArrayLength = DefaultLogLimits.MaxArrayLength
+	}
+	if limits.MaxDepth <= 0 {
+		limits.MaxDepth = DefaultLogLimits.MaxDepth
+	}
+	if limits.MaxEntrySize <= 0 {
+		limits.MaxEntrySize = DefaultLogLimits.MaxEntrySize
+	}
+
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	logLimits = limits
+}
+
+func currentLogLimits() LogLimits {
+	limitsMu.RLock()
+	defer limitsMu.RUnlock()
+	return logLimits
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// sanitizeValue converts v into plain JSON types within the log limits
+func sanitizeValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	limits := currentLogLimits()
+	return truncateGeneric(plainValue(v, limits), limits, 0)
+}
+
+// redactedValue is sanitizeValue with redaction applied before the limits,
+// so that a secret cut by truncation still matches and is masked
+func redactedValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	limits := currentLogLimits()
+	return truncateGeneric(redactValue(plainValue(v, limits)), limits, 0)
+}
+
+// redactedString redacts s before truncating it to the string limit
+func redactedString(s string) string {
+	return truncateString(redactString(s), currentLogLimits().MaxStringLength)
+}
+
+// plainValue converts v into plain JSON types without truncating it
+func plainValue(v interface{}, limits LogLimits) interface{} {
+	// Fast path, values encoding/json handles are decoded
+	if encoded, err := json.Marshal(v); err == nil {
+		if generic, err := decodeGeneric(encoded); err == nil {
+			return generic
+		}
+	}
+
+	s := &sanitizer{
+		limits:   limits,
+		visiting: make(map[uintptr]bool),
+	}
+	return s.value(reflect.ValueOf(v), 0)
+}
+
+// decodeGeneric decodes JSON keeping numbers exact
+func decodeGeneric(encoded []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// truncateGeneric applies string, array and depth limits to plain JSON values
+func truncateGeneric(v interface{}, limits LogLimits, depth int) interface{} {
+	switch x := v.(type) {
+	case string:
+		return truncateString(x, limits.MaxStringLength)
+	case []interface{}:
+		if depth >= limits.MaxDepth {
+			return "[max depth exceeded]"
+		}
+		keep := len(x)
+		if keep > limits.MaxArrayLength {
+			keep = limits.MaxArrayLength
+		}
+		out := make([]interface{}, 0, keep+1)
+		for _, item := range x[:keep] {
+			out = append(out, truncateGeneric(item, limits, depth+1))
+		}
+		if len(x) > keep {
+			out = append(out, fmt.Sprintf("[truncated: %d more items]", len(x)-keep))
+		}
+		return out
+	case map[string]interface{}:
+		if depth >= limits.MaxDepth {
+			return "[max depth exceeded]"
+		}
+		keys := make([]string, 0, len(x))
+		for key := range x {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		out := make(map[string]interface{}, len(keys))
+		for i, key := range keys {
+			if i >= limits.MaxArrayLength {
+				out["_truncated"] = fmt.Sprintf("[truncated: %d more entries]", len(keys)-i)
+				break
+			}
+			out[key] = truncateGeneric(x[key], limits, depth+1)
+		}
+		return out
+	}
+	return v
+}
+
+// truncateString cuts s to at most max bytes on a rune boundary
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s…[truncated: %d more bytes]", s[:cut], len(s)-cut)
+}
+
+// sanitizer walks values encoding/json rejects, mirroring its encoding rules
+type sanitizer struct {
+	limits   LogLimits
+	visiting map[uintptr]bool
+}
+
+func (s *sanitizer) value(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > s.limits.MaxDepth {
+		return "[max depth exceeded]"
+	}
+
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface || v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.IsNil() {
+		return nil
+	}
+
+	// Values with their own encoding
+	if v.Type().Implements(jsonMarshalerType) {
+		encoded, err := v.Interface().(json.Marshaler).MarshalJSON()
+		if err == nil {
+			var generic interface{}
+			if generic, err = decodeGeneric(encoded); err == nil {
+				return generic
+			}
+		}
+		return fmt.Sprintf("[unencodable %s: %v]", v.Type(), err)
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return fmt.Sprintf("[unencodable %s: %v]", v.Type(), err)
+		}
+		return string(text)
+	}
+	if v.Type().Implements(errorType) {
+		return v.Interface().(error).Error()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		return f
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprintf("%v", v.Complex())
+	case reflect.String:
+		return v.String()
+	case reflect.Interface:
+		return s.value(v.Elem(), depth)
+	case reflect.Ptr:
+		return s.enter(v, func() interface{} {
+			return s.value(v.Elem(), depth)
+		})
+	case reflect.Map:
+		return s.enter(v, func() interface{} {
+			return s.mapValue(v, depth)
+		})
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			encoded, _ := json.Marshal(v.Bytes())
+			var text string
+			json.Unmarshal(encoded, &text)
+			return text
+		}
+		return s.enter(v, func() interface{} {
+			return s.listValue(v, depth)
+		})
+	case reflect.Array:
+		return s.listValue(v, depth)
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		s.structFields(v, depth, out)
+		return out
+	}
+
+	// Channels, funcs and unsafe pointers
+	return fmt.Sprintf("[unencodable %s]", v.Type())
+}
+
+// enter guards reference values against cycles
+func (s *sanitizer) enter(v reflect.Value, walk func() interface{}) interface{} {
+	ptr := v.Pointer()
+	if s.visiting[ptr] {
+		return fmt.Sprintf("[cycle %s]", v.Type())
+	}
+	s.visiting[ptr] = true
+	defer delete(s.visiting, ptr)
+	return walk()
+}
+
+func (s *sanitizer) mapValue(v reflect.Value, depth int) interface{} {
+	out := make(map[string]interface{}, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		out[mapKeyString(iter.Key())] = s.value(iter.Value(), depth+1)
+	}
+	return out
+}
+
+func (s *sanitizer) listValue(v reflect.Value, depth int) interface{} {
+	out := make([]interface{}, v.Len())
+	for i := range out {
+		out[i] = s.value(v.Index(i), depth+1)
+	}
+	return out
+}
+
+// structFields adds exported fields to out following encoding/json tag rules.
+// Untagged embedded structs are flattened into their parent.
+func (s *sanitizer) structFields(v reflect.Value, depth int, out map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		value := v.Field(i)
+
+		if field.Anonymous && name == "" {
+			embedded := value
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.structFields(embedded, depth, out)
+				continue
+			}
+			if field.PkgPath != "" {
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(","+options+",", ",omitempty,") && isEmptyValue(value) {
+			continue
+		}
+		if _, exists := out[name]; exists {
+			continue
+		}
+		out[name] = s.value(value, depth+1)
+	}
+}
+
+func mapKeyString(key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+	if key.Type().Implements(textMarshalerType) {
+		if text, err := key.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	return fmt.Sprintf("%v", key.Interface())
+}
+
+// isEmptyValue matches the omitempty rules of encoding/json
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// enforceEntrySize replaces the data of an oversized log entry with a
+// truncated preview and shortens its message if that is not enough
+func enforceEntrySize(params *LogParams) {
+	limits := currentLogLimits()
+
+	encoded, err := json.Marshal(params)
+	if err != nil || len(encoded) <= limits.MaxEntrySize {
+		return
+	}
+
+	if params.Data != nil {
+		data, _ := json.Marshal(params.Data)
+		preview := limits.MaxEntrySize / 4
+		truncated := map[string]interface{}{
+			"_truncated":    true,
+			"original_size": len(encoded),
+			"preview":       truncateString(string(data), preview),
+		}
+		// Keep the trace ids so the entry can still be correlated
+		if fields, ok := params.Data.(map[string]interface{}); ok {
+			for _, key := range []string{"trace_id", "span_id"} {
+				if value, exists := fields[key]; exists {
+					truncated[key] = value
+				}
+			}
+		}
+		params.Data = truncated
+		if encoded, err = json.Marshal(params); err != nil || len(encoded) <= limits.MaxEntrySize {
+			return
+		}
+	}
+
+	params.Message = truncateString(params.Message, limits.MaxEntrySize/2)
+}
@@ -0,0 +1,234 @@
+// EYWA Child Tasks for Go
+//
+// A robot can fan work out to other robots. SpawnTask queues a task for
+// another robot as a child of the current task, so the EYWA UI shows the
+// hierarchy, and passes the trace on through the child's data, where Run
+// and ImportTrace pick it up. The returned ChildTask follows the child
+// until it finishes and logs its outcome in the parent task.
+
+package eywa
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultSpawnPollInterval is how often a child task is checked while waiting
+const DefaultSpawnPollInterval = 2 * time.Second
+
+// waitWarningInterval limits how often Wait logs failing checks
+const waitWarningInterval = time.Minute
+
+// SpawnOptions configures a spawned child task
+type SpawnOptions struct {
+	Message      string        `json:"message,omitempty"`       // Task message, defaults to "<robot> (child of <parent>)"
+	PollInterval time.Duration `json:"poll_interval,omitempty"` // Wait polling interval, defaults to DefaultSpawnPollInterval
+}
+
+// ChildTask is a task spawned by the current robot
+type ChildTask struct {
+	EUUID  string
+	Robot  string
+	Parent string
+
+	ctx      context.Context
+	interval time.Duration
+
+	mu       sync.Mutex
+	result   *TaskResult
+	reported bool
+}
+
+// SpawnTask queues a task for robot with data as a child of the current task
+//
+// Example:
+//
+//	child, err := eywa.SpawnTask("invoice-export", map[string]interface{}{"year": 2024})
+//	if err != nil {
+//	    return err
+//	}
+//	result, err := child.Wait(ctx)
+func SpawnTask(robot string, data interface{}) (*ChildTask, error) {
+	return SpawnTaskWith(context.Background(), robot, data, nil)
+}
+
+// SpawnTaskWith queues a child task within ctx. robot is a robot name or euuid.
+func SpawnTaskWith(ctx context.Context, robot string, data interface{}, options *SpawnOptions) (*ChildTask, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	opts := SpawnOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultSpawnPollInterval
+	}
+
+	parent, err := currentTaskFor(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot spawn task: no active task found: %v", err)
+	}
+	if opts.Message == "" {
+		opts.Message = fmt.Sprintf("%s (child of %s)", robot, parent.EUUID)
+	}
+
+	robotRef := map[string]interface{}{"name": robot}
+	if uuidPattern.MatchString(robot) {
+		robotRef = map[string]interface{}{"euuid": robot}
+	}
+
+	ctx, span := StartSpan(ctx)
+	child := &ChildTask{
+		EUUID:    generateUUID(),
+		Robot:    robot,
+		Parent:   parent.EUUID,
+		ctx:      ctx,
+		interval: opts.PollInterval,
+	}
+
+	mutation := `
+		mutation SpawnTask($task: TaskInput!) {
+			stackTask(data: $task) {
+				euuid
+				status
+			}
+		}
+	`
+	_, err = graphQLContext(ctx, mutation, map[string]interface{}{
+		"task": map[string]interface{}{
+			"euuid":   child.EUUID,
+			"message": opts.Message,
+			"robot":   robotRef,
+			"parent":  map[string]interface{}{"euuid": parent.EUUID},
+			"data":    childTaskData(data, span),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot spawn task for %s: %v", robot, err)
+	}
+
+	WithContext(ctx).Info(fmt.Sprintf("Spawned child task for %s", robot), map[string]interface{}{
+		"task":  child.EUUID,
+		"robot": robot,
+	})
+	return child, nil
+}
+
+// Status fetches the current status of the child task
+func (c *ChildTask) Status() (Status, error) {
+	result, err := c.refresh()
+	if err != nil {
+		return "", err
+	}
+	return result.Status, nil
+}
+
+// Result returns the outcome of the child task. Before the child finishes
+// the result only carries its current status.
+func (c *ChildTask) Result() (*TaskResult, error) {
+	c.mu.Lock()
+	result := c.result
+	c.mu.Unlock()
+	if result != nil && result.Status.CanClose() {
+		return result, nil
+	}
+	return c.refresh()
+}
+
+// Wait polls the child task until it finishes or ctx is done. The child's
+// outcome is logged in the parent task; failing checks are logged at most
+// once a minute.
+func (c *ChildTask) Wait(ctx context.Context) (*TaskResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var lastWarning time.Time
+	failures := 0
+	for {
+		result, err := c.Result()
+		if err == nil && result.Status.CanClose() {
+			return result, nil
+		}
+		if err != nil {
+			// Log the first failure, then at most once per interval
+			failures++
+			if time.Since(lastWarning) >= waitWarningInterval {
+				message := fmt.Sprintf("Cannot check child task %s", c.EUUID)
+				if failures > 1 {
+					message = fmt.Sprintf("%s (%d failed checks)", message, failures)
+				}
+				WithContext(c.ctx).LogErr(WARN, message, err)
+				lastWarning = time.Now()
+			}
+		}
+		if err := sleepContext(ctx, c.interval); err != nil {
+			return nil, fmt.Errorf("waiting for child task %s: %w", c.EUUID, err)
+		}
+	}
+}
+
+// refresh fetches the child task and logs its outcome once it finished
+func (c *ChildTask) refresh() (*TaskResult, error) {
+	result, err := GetTaskResult(c.EUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.result = result
+	report := result.Status.CanClose() && !c.reported
+	if report {
+		c.reported = true
+	}
+	c.mu.Unlock()
+
+	if report {
+		c.logOutcome(result)
+	}
+	return result, nil
+}
+
+// logOutcome logs the finished child task in the parent task
+func (c *ChildTask) logOutcome(result *TaskResult) {
+	data := map[string]interface{}{
+		"task":   c.EUUID,
+		"robot":  c.Robot,
+		"status": result.Status,
+	}
+	if result.Result != nil {
+		data["result"] = result.Result
+	}
+	if len(result.Files) > 0 {
+		data["files"] = result.Files
+	}
+
+	level := INFO
+	if result.Status.Failed() {
+		level = LOG_ERROR
+	}
+	WithContext(c.ctx).Log(level, fmt.Sprintf("Child task %s finished: %s", c.Robot, result.Status), data, nil, nil, nil)
+}
+
+// childTaskData adds the traceparent of span to object data. Other data is
+// sent unchanged.
+func childTaskData(data interface{}, span SpanContext) interface{} {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	object, ok := data.(map[string]interface{})
+	if !ok {
+		return data
+	}
+
+	clone := make(map[string]interface{}, len(object)+1)
+	for key, value := range object {
+		clone[key] = value
+	}
+	if _, exists := clone["traceparent"]; !exists {
+		clone["traceparent"] = span.Traceparent()
+	}
+	return clone
+}
@@ -0,0 +1,31 @@
+package eywa
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChildTaskWaitThrottlesWarnings(t *testing.T) {
+	var output bytes.Buffer
+	// No GraphQL endpoint, so every check fails
+	EnableDevMode(&DevOptions{Output: &output, NoColor: true})
+
+	child := &ChildTask{
+		EUUID:    "c9d8e7f6-a5b4-4c3d-9e8f-7a6b5c4d3e2f",
+		Robot:    "export",
+		ctx:      context.Background(),
+		interval: time.Millisecond,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := child.Wait(ctx); err == nil {
+		t.Fatal("Wait succeeded without a runtime")
+	}
+	if warnings := strings.Count(output.String(), "Cannot check child task"); warnings != 1 {
+		t.Errorf("logged %d warnings, want 1:\n%s", warnings, output.String())
+	}
+}
@@ -0,0 +1,472 @@
+// EYWA Typed Task Access for Go
+//
+// GetTask returns the raw task.get result. GetTaskInfo decodes it into a
+// Task struct, and DecodeTaskData / TaskData[T] decode the task's data
+// payload into user structs with descriptive errors for missing and
+// mistyped fields.
+//
+// The current task is cached after the first lookup, so Report and other
+// APIs that need the task identity don't round-trip task.get every call.
+
+package eywa
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Task is the task a robot is running
+type Task struct {
+	EUUID      string                 `json:"euuid"`
+	Message    string                 `json:"message,omitempty"`
+	Status     Status                 `json:"status,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	Started    *time.Time             `json:"started,omitempty"`
+	Finished   *time.Time             `json:"finished,omitempty"`
+	ModifiedOn *time.Time             `json:"modified_on,omitempty"`
+	Parent     *TaskRef               `json:"parent,omitempty"`
+	Raw        map[string]interface{} `json:"-"` // Complete task.get result
+}
+
+// TaskRef references another task
+type TaskRef struct {
+	EUUID   string `json:"euuid"`
+	Message string `json:"message,omitempty"`
+}
+
+// TaskDataError describes a task data field that could not be decoded
+type TaskDataError struct {
+	Field    string `json:"field"`              // Path of the field, e.g. "invoice.lines[2].amount"
+	Problem  string `json:"problem"`            // "missing", "type" or "invalid"
+	Expected string `json:"expected,omitempty"` // Expected Go type for type and invalid errors
+	Got      string `json:"got,omitempty"`      // JSON type found for type errors
+	Reason   string `json:"reason,omitempty"`   // Error of the type's own decoder for invalid errors
+}
+
+func (e TaskDataError) Error() string {
+	switch e.Problem {
+	case "missing":
+		return fmt.Sprintf("task data field %s is required", e.Field)
+	case "invalid":
+		return fmt.Sprintf("task data field %s is not a valid %s: %s", e.Field, e.Expected, e.Reason)
+	}
+	return fmt.Sprintf("task data field %s must be %s, got %s", e.Field, e.Expected, e.Got)
+}
+
+// TaskDataErrors lists every problem found while decoding task data
+type TaskDataErrors []TaskDataError
+
+func (e TaskDataErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "invalid task data: " + strings.Join(messages, "; ")
+}
+
+// Cached current task, guarded by taskMu
+var (
+	taskMu      sync.Mutex
+	currentTask *Task
+)
+
+// GetTaskInfo retrieves the current task as a typed Task. It always asks
+// the runtime and refreshes the cached task.
+func GetTaskInfo() (*Task, error) {
+	taskMu.Lock()
+	defer taskMu.Unlock()
+	return refreshTaskLocked()
+}
+
+// CurrentTask returns the cached current task, fetching it on first use.
+// The returned Task is shared and must not be modified.
+func CurrentTask() (*Task, error) {
+	taskMu.Lock()
+	defer taskMu.Unlock()
+	if currentTask != nil {
+		return currentTask, nil
+	}
+	return refreshTaskLocked()
+}
+
+// RefreshTask fetches the current task again and replaces the cached one
+func RefreshTask() (*Task, error) {
+	return GetTaskInfo()
+}
+
+// refreshTaskLocked fetches the task, callers must hold taskMu
+func refreshTaskLocked() (*Task, error) {
+	result, err := GetTask()
+	if err != nil {
+		return nil, err
+	}
+	task, err := taskFromResult(result)
+	if err != nil {
+		return nil, err
+	}
+	currentTask = task
+	return task, nil
+}
+
+// DecodeTaskData decodes the data of the current task into target, which
+// must be a pointer. Struct fields tagged `eywa:"required"` must be present.
+//
+// Example:
+//
+//	var input struct {
+//	    Year    int      `json:"year" eywa:"required"`
+//	    Sources []string `json:"sources"`
+//	}
+//	if err := eywa.DecodeTaskData(&input); err != nil {
+//	    return err
+//	}
+func DecodeTaskData(target interface{}) error {
+	task, err := CurrentTask()
+	if err != nil {
+		return err
+	}
+	return decodeTaskData(task.Raw["data"], target)
+}
+
+// TaskData decodes the data of the current task into a value of type T
+//
+// Example:
+//
+//	input, err := eywa.TaskData[ImportInput]()
+func TaskData[T any]() (T, error) {
+	var value T
+	err := DecodeTaskData(&value)
+	return value, err
+}
+
+// taskFromResult converts a task.get result into a Task
+func taskFromResult(result interface{}) (*Task, error) {
+	raw, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid task data format")
+	}
+
+	task := &Task{Raw: raw}
+	if euuid, exists := raw["euuid"]; exists && euuid != nil {
+		task.EUUID = fmt.Sprintf("%v", euuid)
+	} else if id, exists := raw["id"]; exists && id != nil {
+		task.EUUID = fmt.Sprintf("%v", id)
+	} else {
+		return nil, fmt.Errorf("task UUID not found in task data")
+	}
+
+	task.Message, _ = raw["message"].(string)
+	status, _ := raw["status"].(string)
+	task.Status = Status(status)
+	task.Data, _ = raw["data"].(map[string]interface{})
+	task.Started = parseTaskTime(raw["started"])
+	task.Finished = parseTaskTime(raw["finished"])
+	task.ModifiedOn = parseTaskTime(raw["modified_on"])
+
+	if parent, ok := raw["parent"].(map[string]interface{}); ok && parent["euuid"] != nil {
+		task.Parent = &TaskRef{EUUID: fmt.Sprintf("%v", parent["euuid"])}
+		task.Parent.Message, _ = parent["message"].(string)
+	}
+
+	return task, nil
+}
+
+// parseTaskTime accepts RFC 3339 strings and unix timestamps (seconds or milliseconds)
+func parseTaskTime(value interface{}) *time.Time {
+	switch v := value.(type) {
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return &t
+			}
+		}
+	case float64:
+		var t time.Time
+		if v > 1e12 {
+			t = time.UnixMilli(int64(v))
+		} else {
+			t = time.Unix(int64(v), 0)
+		}
+		return &t
+	}
+	return nil
+}
+
+// decodeTaskData decodes raw task data into target, reporting missing
+// required fields and type mismatches
+func decodeTaskData(data interface{}, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("task data target must be a non-nil pointer, got %T", target)
+	}
+	if data == nil {
+		return TaskDataErrors{{Field: "data", Problem: "missing"}}
+	}
+
+	var problems TaskDataErrors
+	checkRequiredFields(value.Type().Elem(), data, "", &problems)
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("cannot encode task data: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	if err := decoder.Decode(target); err != nil {
+		// Decode stops at the first problem, find them all
+		before := len(problems)
+		checkFieldTypes(value.Type().Elem(), encoded, "", &problems)
+		if len(problems) == before {
+			problems = append(problems, decodeProblem(err, value.Type().Elem()))
+		}
+	}
+
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// decodeProblem describes a decode error that checkFieldTypes could not
+// attribute to a field
+func decodeProblem(err error, t reflect.Type) TaskDataError {
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		field := typeErr.Field
+		if field == "" {
+			field = "data"
+		}
+		return TaskDataError{
+			Field:    field,
+			Problem:  "type",
+			Expected: typeErr.Type.String(),
+			Got:      typeErr.Value,
+		}
+	}
+	return TaskDataError{Field: "data", Problem: "invalid", Expected: t.String(), Reason: err.Error()}
+}
+
+// checkRequiredFields walks struct types tagged `eywa:"required"` and
+// records fields that are absent or null in data
+func checkRequiredFields(t reflect.Type, data interface{}, path string, problems *TaskDataErrors) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := data.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, field := range jsonFields(t) {
+			fieldPath := field.name
+			if path != "" {
+				fieldPath = path + "." + field.name
+			}
+			value, exists := lookupJSONField(object, field.name)
+			if !exists || value == nil {
+				if field.required {
+					*problems = append(*problems, TaskDataError{Field: fieldPath, Problem: "missing"})
+				}
+				continue
+			}
+			checkRequiredFields(field.typ, value, fieldPath, problems)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := data.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			checkRequiredFields(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	case reflect.Map:
+		object, ok := data.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for key, item := range object {
+			checkRequiredFields(t.Elem(), item, path+"."+key, problems)
+		}
+	}
+}
+
+// checkFieldTypes decodes raw field by field and records every type
+// mismatch, and every value rejected by a type's own decoder, with its path
+func checkFieldTypes(t reflect.Type, raw json.RawMessage, path string, problems *TaskDataErrors) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return
+	}
+
+	custom := reflect.PtrTo(t).Implements(jsonUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType)
+	if !custom {
+		switch t.Kind() {
+		case reflect.Struct:
+			var object map[string]json.RawMessage
+			if json.Unmarshal(raw, &object) != nil {
+				break
+			}
+			for _, field := range jsonFields(t) {
+				if value, exists := lookupRawField(object, field.name); exists {
+					checkFieldTypes(field.typ, value, joinFieldPath(path, field.name), problems)
+				}
+			}
+			return
+		case reflect.Slice, reflect.Array:
+			if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+				break // []byte is decoded from a base64 string
+			}
+			var items []json.RawMessage
+			if json.Unmarshal(raw, &items) != nil {
+				break
+			}
+			for i, item := range items {
+				checkFieldTypes(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+			return
+		case reflect.Map:
+			var object map[string]json.RawMessage
+			if json.Unmarshal(raw, &object) != nil {
+				break
+			}
+			keys := make([]string, 0, len(object))
+			for key := range object {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				checkFieldTypes(t.Elem(), object[key], joinFieldPath(path, key), problems)
+			}
+			return
+		}
+	}
+
+	err := json.Unmarshal(raw, reflect.New(t).Interface())
+	if err == nil {
+		return
+	}
+	field := path
+	if field == "" {
+		field = "data"
+	}
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		*problems = append(*problems, TaskDataError{
+			Field:    field,
+			Problem:  "type",
+			Expected: t.String(),
+			Got:      typeErr.Value,
+		})
+		return
+	}
+	*problems = append(*problems, TaskDataError{
+		Field:    field,
+		Problem:  "invalid",
+		Expected: t.String(),
+		Reason:   err.Error(),
+	})
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// lookupRawField finds a raw field the way encoding/json matches names
+func lookupRawField(object map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if value, ok := object[name]; ok {
+		return value, true
+	}
+	for key, value := range object {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// jsonField is a struct field as seen by encoding/json
+type jsonField struct {
+	name     string
+	typ      reflect.Type
+	required bool
+	tag      string // Raw eywa tag
+}
+
+// jsonFields lists the fields encoding/json decodes into, flattening
+// untagged embedded structs
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(embedded)...)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		tag = field.Tag.Get("eywa")
+		fields = append(fields, jsonField{
+			name:     name,
+			typ:      field.Type,
+			required: hasTagOption(tag, "required"),
+			tag:      tag,
+		})
+	}
+	return fields
+}
+
+// lookupJSONField finds a key the way encoding/json does, preferring an
+// exact match over a case-insensitive one
+func lookupJSONField(object map[string]interface{}, name string) (interface{}, bool) {
+	if value, ok := object[name]; ok {
+		return value, true
+	}
+	for key, value := range object {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func hasTagOption(tag, option string) bool {
+	for _, part := range strings.Split(tag, ",") {
+		if strings.TrimSpace(part) == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package eywa
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestDecodeTaskDataReportsEveryField(t *testing.T) {
+	type line struct {
+		Amount float64 `json:"amount"`
+	}
+	type input struct {
+		Year    int        `json:"year"`
+		Count   int        `json:"count"`
+		Name    string     `json:"name" eywa:"required"`
+		Lines   []line     `json:"lines"`
+		Comment *string    `json:"comment"`
+		Since   *time.Time `json:"since"`
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"valid", `{"year":2024,"count":1,"name":"a","lines":[{"amount":1.5}],"comment":null}`, nil},
+		{"two mistyped fields", `{"year":"x","count":"y","name":"a"}`, []string{"count:type", "year:type"}},
+		{"missing and mistyped", `{"year":"x"}`, []string{"name:missing", "year:type"}},
+		{"nested", `{"name":"a","lines":[{"amount":1},{"amount":"2"},{"amount":true}]}`, []string{"lines[1].amount:type", "lines[2].amount:type"}},
+		{"wrong container", `{"name":"a","lines":{"amount":1}}`, []string{"lines:type"}},
+		{"custom decoder", `{"since":"yesterday","year":"x"}`, []string{"name:missing", "since:invalid", "year:type"}},
+		{"valid custom value", `{"name":"a","since":"2024-01-31T00:00:00Z"}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tt.data), &data); err != nil {
+				t.Fatal(err)
+			}
+
+			var target input
+			err := decodeTaskData(data, &target)
+			var got []string
+			if err != nil {
+				problems, ok := err.(TaskDataErrors)
+				if !ok {
+					t.Fatalf("unexpected error type %T: %v", err, err)
+				}
+				for _, problem := range problems {
+					got = append(got, problem.Field+":"+problem.Problem)
+				}
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+// EYWA Trace Propagation for Go
+//
+// Correlates robot logs, reports and GraphQL calls with server-side traces.
+// Trace and span ids follow the W3C Trace Context format and travel in
+// context.Context:
+// - log entries get "trace_id" and "span_id" fields in their data
+// - outgoing JSON-RPC messages carry a "traceparent" member
+// - a trace can be imported from the task data, so a chain of robots
+//   shares one trace
+
+package eywa
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SpanContext identifies a span within a distributed trace
+type SpanContext struct {
+	TraceID string `json:"trace_id"`
+	SpanID  string `json:"span_id"`
+	Sampled bool   `json:"sampled"`
+}
+
+type spanContextKey struct{}
+
+var (
+	traceMu      sync.RWMutex
+	defaultTrace SpanContext
+)
+
+// NewTrace starts a new sampled trace with a root span
+func NewTrace() SpanContext {
+	return SpanContext{
+		TraceID: randomHex(16),
+		SpanID:  randomHex(8),
+		Sampled: true,
+	}
+}
+
+// NewSpan returns a child span within the same trace
+func (s SpanContext) NewSpan() SpanContext {
+	return SpanContext{
+		TraceID: s.TraceID,
+		SpanID:  randomHex(8),
+		Sampled: s.Sampled,
+	}
+}
+
+// IsValid reports whether the trace and span ids are well formed and non-zero
+func (s SpanContext) IsValid() bool {
+	return isTraceHex(s.TraceID, 32) && isTraceHex(s.SpanID, 16)
+}
+
+// Traceparent formats the span as a W3C traceparent header value
+func (s SpanContext) Traceparent() string {
+	flags := "00"
+	if s.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", s.TraceID, s.SpanID, flags)
+}
+
+// ParseTraceparent parses a W3C traceparent value ("00-<trace-id>-<span-id>-<flags>")
+func ParseTraceparent(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, fmt.Errorf("invalid traceparent: %q", value)
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, fmt.Errorf("invalid traceparent: %q", value)
+	}
+
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return SpanContext{}, fmt.Errorf("invalid traceparent flags: %q", value)
+	}
+
+	span := SpanContext{
+		TraceID: strings.ToLower(parts[1]),
+		SpanID:  strings.ToLower(parts[2]),
+		Sampled: flags[0]&0x01 == 0x01,
+	}
+	if !span.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent ids: %q", value)
+	}
+	return span, nil
+}
+
+// ContextWithSpan returns a copy of ctx carrying span
+func ContextWithSpan(ctx context.Context, span SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span carried by ctx
+func SpanFromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	span, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return span, ok && span.IsValid()
+}
+
+// StartSpan returns a context with a new child span of the span in ctx.
+// Without a span in ctx the default trace is continued, or a new trace is
+// started when there is none.
+//
+// Example:
+//
+//	ctx, _ := eywa.StartSpan(ctx)
+//	eywa.WithContext(ctx).Info("Fetching invoices", nil)
+func StartSpan(ctx context.Context) (context.Context, SpanContext) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var span SpanContext
+	if parent, ok := spanFor(ctx); ok {
+		span = parent.NewSpan()
+	} else {
+		span = NewTrace()
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// SetDefaultTrace sets the span used by calls without a span in their
+// context, including all package-level functions like Info and GraphQL
+func SetDefaultTrace(span SpanContext) {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	defaultTrace = span
+}
+
+// DefaultTrace returns the span used by calls without a span in their context
+func DefaultTrace() (SpanContext, bool) {
+	traceMu.RLock()
+	defer traceMu.RUnlock()
+	return defaultTrace, defaultTrace.IsValid()
+}
+
+// ImportTrace continues the trace passed in the task ("traceparent" on the
+// task or in its data) with a new span, or starts a new trace when the task
+// has none. The span becomes the default trace and is returned in ctx.
+//
+// Example:
+//
+//	ctx, err := eywa.ImportTrace(context.Background())
+func ImportTrace(ctx context.Context) (context.Context, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	span := NewTrace()
+	var importErr error
+
+	task, err := CurrentTask()
+	if err != nil {
+		importErr = fmt.Errorf("cannot import trace: %v", err)
+	} else if value := taskTraceparent(task.Raw); value != "" {
+		parent, err := ParseTraceparent(value)
+		if err != nil {
+			importErr = err
+		} else {
+			span = parent.NewSpan()
+		}
+	}
+
+	SetDefaultTrace(span)
+	return ContextWithSpan(ctx, span), importErr
+}
+
+// taskTraceparent finds a traceparent value on the task or in its data
+func taskTraceparent(taskMap map[string]interface{}) string {
+	if value, ok := taskMap["traceparent"].(string); ok {
+		return value
+	}
+	if data, ok := taskMap["data"].(map[string]interface{}); ok {
+		if value, ok := data["traceparent"].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// spanFor returns the span of ctx, falling back to the default trace
+func spanFor(ctx context.Context) (SpanContext, bool) {
+	if span, ok := SpanFromContext(ctx); ok {
+		return span, true
+	}
+	return DefaultTrace()
+}
+
+// applyTrace adds the traceparent member to an outgoing JSON-RPC message
+func applyTrace(ctx context.Context, data map[string]interface{}) {
+	if span, ok := spanFor(ctx); ok {
+		data["traceparent"] = span.Traceparent()
+	}
+}
+
+// withTraceData adds trace and span ids to redacted log data. Data that is
+// not an object is wrapped under "value".
+func withTraceData(ctx context.Context, data interface{}) interface{} {
+	span, ok := spanFor(ctx)
+	if !ok {
+		return data
+	}
+
+	var fields map[string]interface{}
+	switch x := data.(type) {
+	case nil:
+		fields = make(map[string]interface{})
+	case map[string]interface{}:
+		fields = x
+	default:
+		fields = map[string]interface{}{"value": data}
+	}
+
+	if _, exists := fields["trace_id"]; !exists {
+		fields["trace_id"] = span.TraceID
+	}
+	if _, exists := fields["span_id"]; !exists {
+		fields["span_id"] = span.SpanID
+	}
+	return fields
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// isTraceHex validates a lowercase hex id of the given length that is not all zeros
+func isTraceHex(value string, length int) bool {
+	if len(value) != length || strings.Trim(value, "0") == "" {
+		return false
+	}
+	for _, c := range value {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
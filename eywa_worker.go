@@ -0,0 +1,423 @@
+// EYWA Service Mode for Go
+//
+// A Worker is one long-running process that handles many tasks instead of
+// starting a process per task. It registers the robots it serves with the
+// runtime (worker.register), receives task assignments (task.assign) and
+// runs every task in parallel in its own TaskSession. Messages sent for a
+// session carry a top-level "task" member with the task euuid, and each
+// session has its own lifecycle, so closing a task does not exit the
+// process.
+//
+// Process-wide features (log capture and ReturnTask) stay bound to the
+// process task and are not available to sessions. Package-level functions
+// act on the process task too; handlers use the session and its Logger
+// instead (session.Logger().NewProgress, RunCommand and LogWriter,
+// session.ValidateInput, Update and Close). ValidateTaskInput and CloseTask
+// exit the process and must never be called from a handler.
+
+package eywa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultWorkerConcurrency is how many tasks a worker runs at once by default
+const DefaultWorkerConcurrency = 8
+
+// ErrWorkerBusy is returned to the runtime when all task slots are taken
+var ErrWorkerBusy = errors.New("worker is at capacity")
+
+// TaskHandler does the work of one task in service mode. Returning nil
+// closes the task with SUCCESS, an error closes it with ERROR, unless the
+// handler closed the session itself.
+type TaskHandler func(session *TaskSession) error
+
+// WorkerOptions configures a Worker
+type WorkerOptions struct {
+	Concurrency int           `json:"concurrency,omitempty"`  // Tasks run at once, defaults to DefaultWorkerConcurrency
+	GracePeriod time.Duration `json:"grace_period,omitempty"` // Time running tasks get after Serve is stopped, defaults to DefaultSignalGracePeriod
+}
+
+// Worker runs tasks for registered robots within one process
+type Worker struct {
+	options  WorkerOptions
+	mu       sync.Mutex
+	handlers map[string]TaskHandler
+	slots    chan struct{}
+	running  sync.WaitGroup
+	ctx      context.Context
+	draining bool
+}
+
+// TaskSession is one task handled by a Worker. Its methods log, report,
+// update and close that task only.
+type TaskSession struct {
+	ctx       context.Context
+	task      *Task
+	robot     string
+	lifecycle *taskLifecycle
+	logger    *Logger
+}
+
+type taskSessionKey struct{}
+
+// NewWorker creates a worker
+//
+// Example:
+//
+//	worker := eywa.NewWorker(&eywa.WorkerOptions{Concurrency: 16})
+//	worker.Handle("invoice-check", func(session *eywa.TaskSession) error {
+//	    var input CheckInput
+//	    if err := session.DecodeData(&input); err != nil {
+//	        return err
+//	    }
+//	    session.Logger().Info("Checking invoice", input)
+//	    return checkInvoice(session.Context(), input)
+//	})
+//	log.Fatal(worker.Serve(context.Background()))
+func NewWorker(options *WorkerOptions) *Worker {
+	opts := WorkerOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultWorkerConcurrency
+	}
+	if opts.GracePeriod <= 0 {
+		opts.GracePeriod = DefaultSignalGracePeriod
+	}
+
+	return &Worker{
+		options:  opts,
+		handlers: make(map[string]TaskHandler),
+		slots:    make(chan struct{}, opts.Concurrency),
+	}
+}
+
+// Handle registers the handler for tasks of robot
+func (w *Worker) Handle(robot string, handler TaskHandler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[robot] = handler
+}
+
+// HandleRobot registers a Robot, its steps run for every task assigned to it
+func (w *Worker) HandleRobot(robot *Robot) {
+	w.Handle(robot.name, func(session *TaskSession) error {
+		_, err := robot.Execute(session.Context())
+		return err
+	})
+}
+
+// Serve registers the worker with the runtime and handles task assignments
+// until ctx is done or the runtime closes the pipe. Running tasks then get
+// the grace period to finish before their contexts are cancelled. Serve
+// opens the pipe itself, do not call OpenPipe as well.
+func (w *Worker) Serve(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if IsDevMode() {
+		return fmt.Errorf("service mode needs an EYWA runtime, run the worker with %s=0", EnvDev)
+	}
+
+	taskCtx, cancelTasks := context.WithCancel(context.Background())
+	defer cancelTasks()
+
+	w.mu.Lock()
+	w.ctx = taskCtx
+	w.draining = false
+	robots := make([]string, 0, len(w.handlers))
+	for robot := range w.handlers {
+		robots = append(robots, robot)
+	}
+	w.mu.Unlock()
+	sort.Strings(robots)
+
+	RegisterHandler("task.assign", w.assign)
+
+	pipeClosed := make(chan struct{})
+	go func() {
+		OpenPipe()
+		close(pipeClosed)
+	}()
+
+	response := <-SendRequest(map[string]interface{}{
+		"method": "worker.register",
+		"params": map[string]interface{}{
+			"robots":      robots,
+			"concurrency": w.options.Concurrency,
+		},
+	})
+	if response.Error != nil {
+		return fmt.Errorf("worker.register error: %v", response.Error)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-pipeClosed:
+	}
+
+	w.mu.Lock()
+	w.draining = true
+	w.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		w.running.Wait()
+		close(finished)
+	}()
+
+	timer := time.NewTimer(w.options.GracePeriod)
+	defer timer.Stop()
+	select {
+	case <-finished:
+	case <-timer.C:
+		cancelTasks()
+		<-finished
+	}
+	return nil
+}
+
+// assign accepts a task.assign request and runs the task in the background
+func (w *Worker) assign(request Request) {
+	params, _ := request.Params.(map[string]interface{})
+	raw := params["task"]
+	if raw == nil {
+		raw = request.Params
+	}
+
+	task, err := taskFromResult(raw)
+	if err != nil {
+		w.reject(request, err)
+		return
+	}
+
+	robot, _ := params["robot"].(string)
+	if robot == "" {
+		if ref, ok := task.Raw["robot"].(map[string]interface{}); ok {
+			robot, _ = ref["name"].(string)
+		}
+	}
+
+	w.mu.Lock()
+	handler, exists := w.handlers[robot]
+	draining := w.draining
+	base := w.ctx
+	w.mu.Unlock()
+
+	switch {
+	case !exists:
+		w.reject(request, fmt.Errorf("no handler for robot %q", robot))
+		return
+	case draining:
+		w.reject(request, fmt.Errorf("worker is shutting down"))
+		return
+	}
+
+	select {
+	case w.slots <- struct{}{}:
+	default:
+		w.reject(request, ErrWorkerBusy)
+		return
+	}
+
+	w.running.Add(1)
+	if request.ID != "" {
+		sendResponse(request.ID, map[string]interface{}{"accepted": true, "task": task.EUUID}, nil)
+	}
+
+	go func() {
+		defer w.running.Done()
+		defer func() { <-w.slots }()
+		runSession(newTaskSession(base, task, robot), handler)
+	}()
+}
+
+func (w *Worker) reject(request Request, err error) {
+	if request.ID != "" {
+		sendResponse(request.ID, nil, err)
+		return
+	}
+	lifecycleWarning("rejected task.assign: %v", err)
+}
+
+// newTaskSession prepares the context of a task: the session itself, the
+// trace passed in the task and the task deadline
+func newTaskSession(base context.Context, task *Task, robot string) *TaskSession {
+	session := &TaskSession{
+		task:      task,
+		robot:     robot,
+		lifecycle: &taskLifecycle{},
+	}
+
+	span := NewTrace()
+	if value := taskTraceparent(task.Raw); value != "" {
+		if parent, err := ParseTraceparent(value); err == nil {
+			span = parent.NewSpan()
+		}
+	}
+
+	ctx := context.WithValue(base, taskSessionKey{}, session)
+	session.ctx = ContextWithSpan(ctx, span)
+	session.logger = WithContext(session.ctx)
+	return session
+}
+
+// runSession runs handler and closes the task if the handler did not
+func runSession(session *TaskSession, handler TaskHandler) {
+	ctx := session.ctx
+	deadline, hasDeadline, err := deadlineFromTask(session.task, time.Now())
+	if err != nil {
+		session.logger.LogErr(WARN, "Ignoring task deadline", err)
+	}
+	if hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+		session.ctx = ctx
+		session.logger = session.logger.WithContext(ctx)
+	}
+
+	err = runProtected(ctx, func(context.Context) error {
+		return handler(session)
+	})
+	if session.Closed() {
+		return
+	}
+
+	if hasDeadline && ctx.Err() == context.DeadlineExceeded {
+		session.logger.Error(fmt.Sprintf("Task deadline exceeded at %s", deadline.Format(time.RFC3339)), map[string]interface{}{
+			"error":    ErrTaskDeadlineExceeded.Error(),
+			"deadline": deadline.Format(time.RFC3339),
+		})
+		session.CloseWithResult(ERROR, map[string]interface{}{
+			"error":    ErrTaskDeadlineExceeded.Error(),
+			"deadline": deadline.Format(time.RFC3339),
+		})
+		return
+	}
+
+	if err == nil {
+		session.Close(SUCCESS)
+		return
+	}
+
+	if panicErr, ok := err.(*PanicError); ok {
+		session.logger.Exception(fmt.Sprintf("Task handler panicked: %v", panicErr.Value), map[string]interface{}{
+			"panic": fmt.Sprintf("%v", panicErr.Value),
+			"type":  fmt.Sprintf("%T", panicErr.Value),
+			"stack": panicErr.Stack,
+		})
+	} else {
+		session.logger.LogErr(LOG_ERROR, fmt.Sprintf("Task failed: %v", err), err)
+	}
+	session.Close(ERROR)
+}
+
+// Context returns the context of the task. It carries the task trace and
+// deadline and is cancelled when the worker stops.
+func (s *TaskSession) Context() context.Context {
+	return s.ctx
+}
+
+// Task returns the task being handled
+func (s *TaskSession) Task() *Task {
+	return s.task
+}
+
+// Robot returns the robot the task was assigned to
+func (s *TaskSession) Robot() string {
+	return s.robot
+}
+
+// Logger returns a Logger that logs, reports and queries for this task
+func (s *TaskSession) Logger() *Logger {
+	return s.logger
+}
+
+// DecodeData decodes the task data into target, see DecodeTaskData
+func (s *TaskSession) DecodeData(target interface{}) error {
+	return decodeTaskData(s.task.Raw["data"], target)
+}
+
+// Update updates the task status, message, progress and data, see UpdateTaskWith
+func (s *TaskSession) Update(status Status, options *TaskUpdateOptions) error {
+	return updateTaskContext(s.ctx, status, options)
+}
+
+// Close closes the task with status without exiting the process
+func (s *TaskSession) Close(status Status) error {
+	_, err := closeTaskContext(s.ctx, TaskParams{Status: status}, nil)
+	return err
+}
+
+// CloseWithResult closes the task with a result and output files, see CloseTaskWithResult
+func (s *TaskSession) CloseWithResult(status Status, result interface{}, files ...string) error {
+	_, err := closeTaskContext(s.ctx, resultParams(status, result, files), nil)
+	return err
+}
+
+// ValidateInput validates the task data against schema, see
+// ValidateTaskInput. Violations are logged and the session is closed with
+// ERROR; the process keeps running.
+func (s *TaskSession) ValidateInput(schema *Schema) error {
+	return validateTaskInput(s.ctx, schema, s.Close)
+}
+
+// SaveCheckpoint stores state under key for this task, see SaveCheckpoint
+func (s *TaskSession) SaveCheckpoint(key string, state interface{}) error {
+	return saveCheckpoint(s.ctx, key, state)
+}
+
+// LoadCheckpoint decodes the state saved under key for this task, see LoadCheckpoint
+func (s *TaskSession) LoadCheckpoint(key string, target interface{}) (bool, error) {
+	return loadCheckpoint(s.ctx, key, target)
+}
+
+// ClearCheckpoint deletes the checkpoint saved under key for this task
+func (s *TaskSession) ClearCheckpoint(key string) error {
+	return clearCheckpoint(s.ctx, key)
+}
+
+// Closed reports whether the task was closed
+func (s *TaskSession) Closed() bool {
+	return s.lifecycle.isClosed()
+}
+
+// sessionFor returns the task session carried by ctx
+func sessionFor(ctx context.Context) *TaskSession {
+	if ctx == nil {
+		return nil
+	}
+	session, _ := ctx.Value(taskSessionKey{}).(*TaskSession)
+	return session
+}
+
+// lifecycleFor returns the lifecycle of the task of ctx
+func lifecycleFor(ctx context.Context) *taskLifecycle {
+	if session := sessionFor(ctx); session != nil {
+		return session.lifecycle
+	}
+	return lifecycle
+}
+
+// currentTaskFor returns the task of ctx, the process task by default
+func currentTaskFor(ctx context.Context) (*Task, error) {
+	if session := sessionFor(ctx); session != nil {
+		return session.task, nil
+	}
+	return CurrentTask()
+}
+
+// applyTaskScope adds the task member to messages sent for a session
+func applyTaskScope(ctx context.Context, data map[string]interface{}) {
+	if session := sessionFor(ctx); session != nil {
+		data["task"] = session.task.EUUID
+	}
+}
@@ -0,0 +1,63 @@
+package eywa
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSessionHelpersActOnTheSession(t *testing.T) {
+	var output bytes.Buffer
+	EnableDevMode(&DevOptions{Output: &output, NoColor: true})
+
+	previousLifecycle := lifecycle
+	lifecycle = &taskLifecycle{}
+	defer func() { lifecycle = previousLifecycle }()
+
+	session := newTaskSession(context.Background(), &Task{
+		EUUID: "55555555-5555-4555-8555-555555555555",
+		Raw:   map[string]interface{}{"data": map[string]interface{}{"year": "x"}},
+	}, "robot")
+
+	schema := mustParseSchema(t, `{"properties":{"year":{"type":"integer"}}}`)
+	var violations SchemaViolations
+	if err := session.ValidateInput(schema); !errors.As(err, &violations) {
+		t.Fatalf("got %v, want SchemaViolations", err)
+	}
+	if !session.Closed() {
+		t.Error("session not closed after invalid input")
+	}
+	if lifecycle.isClosed() {
+		t.Error("process task closed by a session")
+	}
+
+	// The session is closed, so everything below must be dropped
+	output.Reset()
+	logger := session.Logger()
+	progress := logger.NewProgress("Importing", 10, &ProgressOptions{UpdateTask: true})
+	progress.Set(10, 10)
+	w := logger.LogWriter(INFO)
+	w.Write([]byte("written line\n"))
+	w.Close()
+	logger.RunCommand(exec.Command("go", "version"))
+
+	if output.Len() > 0 {
+		t.Errorf("closed session still logged or updated:\n%s", output.String())
+	}
+}
+
+func TestLoggerProgressUsesCoordinates(t *testing.T) {
+	var output bytes.Buffer
+	EnableDevMode(&DevOptions{Output: &output, NoColor: true})
+
+	session := newTaskSession(context.Background(), &Task{EUUID: "66666666-6666-4666-8666-666666666666"}, "robot")
+	progress := session.Logger().WithStep("load").NewProgress("Loading", 4, nil)
+	progress.Set(4, 4)
+
+	if !strings.Contains(output.String(), "Loading: 4 / 4") || !strings.Contains(output.String(), "load") {
+		t.Errorf("progress not logged through the session logger:\n%s", output.String())
+	}
+}